@@ -3,6 +3,8 @@ package hash
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"io"
+	"os"
 )
 
 // SHA256 returns the SHA256 hash of the input string as a hexadecimal string.
@@ -30,3 +32,45 @@ func SHA256(str string) string {
 	hash := sha256.Sum256(data)
 	return hex.EncodeToString(hash[:])
 }
+
+// SHA256Reader returns the SHA256 hash of everything read from r as a hexadecimal string.
+// The input is streamed, so it is suitable for large files and network bodies.
+//
+// Parameters:
+//   - r: The reader to consume until EOF
+//
+// Returns:
+//   - string: The SHA256 hash as a hexadecimal string (64 characters)
+//   - error: Any error returned by r
+//
+// Example:
+//
+//	sum, err := hash.SHA256Reader(resp.Body)
+func SHA256Reader(r io.Reader) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// SHA256File returns the SHA256 hash of the named file as a hexadecimal string.
+//
+// Parameters:
+//   - path: The file to hash
+//
+// Returns:
+//   - string: The SHA256 hash as a hexadecimal string (64 characters)
+//   - error: An error if the file cannot be opened or read
+//
+// Example:
+//
+//	sum, err := hash.SHA256File("release.tar.gz")
+func SHA256File(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+	return SHA256Reader(f)
+}
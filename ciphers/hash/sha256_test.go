@@ -1,6 +1,9 @@
 package hash
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/go4x/got"
@@ -51,3 +54,31 @@ func TestSHA256(t *testing.T) {
 		t.Errorf("SHA256(%q) = %q; want %q", unicodeInput, resultUnicode, expectedUnicode)
 	}
 }
+
+func TestSHA256Reader(t *testing.T) {
+	input := "The quick brown fox jumps over the lazy dog"
+	result, err := SHA256Reader(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("SHA256Reader() error = %v", err)
+	}
+	if result != SHA256(input) {
+		t.Errorf("SHA256Reader() = %q; want %q", result, SHA256(input))
+	}
+}
+
+func TestSHA256File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.txt")
+	if err := os.WriteFile(path, []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	result, err := SHA256File(path)
+	if err != nil {
+		t.Fatalf("SHA256File() error = %v", err)
+	}
+	if result != "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824" {
+		t.Errorf("SHA256File() = %q", result)
+	}
+	if _, err := SHA256File(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("SHA256File() expected error for missing file")
+	}
+}
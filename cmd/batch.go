@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// ErrSkipped is the error of batch commands not run because an earlier command
+// failed in fail-fast mode.
+var ErrSkipped = errors.New("skipped after an earlier failure")
+
+// Batch runs independent commands in parallel with a concurrency limit.
+//
+// Commands are started in order. By default every command runs and failures are
+// only reported; with FailFast the first failure stops the running commands and
+// skips the pending ones.
+//
+// Example:
+//
+//	results, errs := cmd.NewBatch().
+//		Add("gofmt", "-l", "./pkg/a").
+//		Add("gofmt", "-l", "./pkg/b").
+//		Then(cmd.NewCommand("go", "vet", "./...").Dir(repo)).
+//		Concurrency(4).
+//		Timeout(time.Minute).
+//		OnProgress(func(e cmd.BatchEvent) {
+//			if e.Done {
+//				log.Printf("[%d/%d] %s: %v", e.Completed, e.Total, e.Command, e.Err)
+//			}
+//		}).
+//		Run()
+type Batch struct {
+	commands    []*Command
+	concurrency int
+	timeout     time.Duration
+	failFast    bool
+	ctx         context.Context
+	onProgress  func(BatchEvent)
+}
+
+// BatchEvent reports the progress of a Batch.
+type BatchEvent struct {
+	// Index is the position of the command in the batch.
+	Index int
+	// Command is the command line.
+	Command string
+	// Done is false when the command starts and true when it finishes.
+	Done bool
+	// Result is the result of the finished command.
+	Result *Result
+	// Err is the error of the finished command.
+	Err error
+	// Completed is the number of finished commands, including this one.
+	Completed int
+	// Total is the number of commands in the batch.
+	Total int
+}
+
+// NewBatch creates a batch of the given commands, run with at most
+// runtime.NumCPU() commands at a time.
+func NewBatch(commands ...*Command) *Batch {
+	return &Batch{commands: commands, concurrency: runtime.NumCPU()}
+}
+
+// Add appends a command running name with the given arguments.
+func (b *Batch) Add(name string, args ...string) *Batch {
+	return b.Then(NewCommand(name, args...))
+}
+
+// Then appends a command, for commands needing more options.
+func (b *Batch) Then(command *Command) *Batch {
+	b.commands = append(b.commands, command)
+	return b
+}
+
+// Concurrency sets the maximum number of commands running at the same time.
+// Values below 1 mean 1.
+func (b *Batch) Concurrency(n int) *Batch {
+	b.concurrency = max(n, 1)
+	return b
+}
+
+// Timeout limits the duration of each command that has no timeout of its own.
+func (b *Batch) Timeout(timeout time.Duration) *Batch {
+	b.timeout = timeout
+	return b
+}
+
+// FailFast stops the running commands and skips the pending ones as soon as one
+// command fails.
+func (b *Batch) FailFast() *Batch {
+	b.failFast = true
+	return b
+}
+
+// Context sets a context that stops the whole batch when done.
+func (b *Batch) Context(ctx context.Context) *Batch {
+	b.ctx = ctx
+	return b
+}
+
+// OnProgress sets a callback invoked when each command starts and finishes.
+// Calls are serialized, so fn needs no locking.
+func (b *Batch) OnProgress(fn func(BatchEvent)) *Batch {
+	b.onProgress = fn
+	return b
+}
+
+// Run runs the batch and returns the results and errors of the commands, in the
+// order they were added. Skipped commands have a result with exit code -1 and
+// ErrSkipped, or the error of the batch context if it was done.
+func (b *Batch) Run() ([]*Result, []error) {
+	total := len(b.commands)
+	results := make([]*Result, total)
+	errs := make([]error, total)
+
+	ctx := b.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex // serializes progress events and failure handling
+		completed int
+		failed    bool
+		sem       = make(chan struct{}, max(b.concurrency, 1))
+	)
+	report := func(e BatchEvent) {
+		if b.onProgress != nil {
+			e.Total = total
+			b.onProgress(e)
+		}
+	}
+
+	for i, command := range b.commands {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		mu.Lock()
+		if ctx.Err() != nil {
+			skipErr := ctx.Err()
+			if failed {
+				skipErr = ErrSkipped
+			}
+			for j := i; j < total; j++ {
+				results[j] = &Result{Command: b.commands[j].String(), ExitCode: -1}
+				errs[j] = skipErr
+			}
+			mu.Unlock()
+			break
+		}
+		report(BatchEvent{Index: i, Command: command.String(), Completed: completed})
+		mu.Unlock()
+
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			res, err := b.run(ctx, command)
+
+			mu.Lock()
+			defer mu.Unlock()
+			results[i], errs[i] = res, err
+			completed++
+			report(BatchEvent{Index: i, Command: res.Command, Done: true, Result: res, Err: err, Completed: completed})
+			if err != nil && b.failFast && !failed {
+				failed = true
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+	return results, errs
+}
+
+// run runs command under the batch context, also honouring the command's own
+// context, and applies the batch timeout if the command has none.
+func (b *Batch) run(ctx context.Context, command *Command) (*Result, error) {
+	if command.ctx != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		defer cancel()
+		defer context.AfterFunc(command.ctx, cancel)()
+	}
+	timeout := command.timeout
+	if timeout == 0 {
+		timeout = b.timeout
+	}
+	captured, err := command.runWith(ctx, timeout, false)
+	return captured.Result, err
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestBatch_Run 测试并行执行与有序结果
+func TestBatch_Run(t *testing.T) {
+	var running, peak int
+	var events []BatchEvent
+	batch := NewBatch().Concurrency(2).OnProgress(func(e BatchEvent) {
+		events = append(events, e)
+		if e.Done {
+			running--
+		} else {
+			running++
+			peak = max(peak, running)
+		}
+	})
+	for _, word := range []string{"a", "b", "c", "d", "e"} {
+		batch.Add("sh", "-c", "sleep 0.1; echo "+word)
+	}
+	batch.Add("sh", "-c", "exit 7")
+
+	start := time.Now()
+	results, errs := batch.Run()
+	elapsed := time.Since(start)
+
+	var outputs []string
+	for _, res := range results[:5] {
+		outputs = append(outputs, strings.TrimSpace(res.Stdout))
+	}
+	if strings.Join(outputs, "") != "abcde" {
+		t.Errorf("Expected ordered results, got %v", outputs)
+	}
+	if errs[5] == nil || results[5].ExitCode != 7 {
+		t.Errorf("Expected the last command to fail, got %+v %v", results[5], errs[5])
+	}
+	for _, err := range errs[:5] {
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+	}
+	if peak > 2 {
+		t.Errorf("Expected at most 2 concurrent commands, got %d", peak)
+	}
+	if elapsed > 2*time.Second || elapsed < 250*time.Millisecond {
+		t.Errorf("Expected 3 waves of 0.1s, took %s", elapsed)
+	}
+	if len(events) != 12 || events[len(events)-1].Completed != 6 || events[0].Total != 6 {
+		t.Errorf("Unexpected progress events %+v", events)
+	}
+}
+
+// TestBatch_FailFast 测试快速失败模式
+func TestBatch_FailFast(t *testing.T) {
+	start := time.Now()
+	results, errs := NewBatch().
+		Add("sleep", "5").
+		Add("sh", "-c", "sleep 0.1; exit 1").
+		Add("echo", "never").
+		Add("echo", "never").
+		Concurrency(2).
+		FailFast().
+		Run()
+	if time.Since(start) > 3*time.Second {
+		t.Errorf("Expected the running command to be stopped, took %s", time.Since(start))
+	}
+	if results[0].Termination != Terminated || !errors.Is(errs[0], context.Canceled) {
+		t.Errorf("Expected the running command to be terminated, got %+v %v", results[0], errs[0])
+	}
+	if results[1].ExitCode != 1 {
+		t.Errorf("Expected the failing command result, got %+v", results[1])
+	}
+	for _, i := range []int{2, 3} {
+		if !errors.Is(errs[i], ErrSkipped) || results[i].ExitCode != -1 || results[i].Command != "echo never" {
+			t.Errorf("Expected command %d to be skipped, got %+v %v", i, results[i], errs[i])
+		}
+	}
+}
+
+// TestBatch_Timeout 测试单个命令超时与批次上下文
+func TestBatch_Timeout(t *testing.T) {
+	results, errs := NewBatch().
+		Add("sleep", "5").
+		Then(NewCommand("sh", "-c", "sleep 0.2; echo own").Timeout(2 * time.Second)).
+		Timeout(100 * time.Millisecond).
+		Run()
+	if !errors.Is(errs[0], context.DeadlineExceeded) {
+		t.Errorf("Expected the batch timeout to apply, got %v", errs[0])
+	}
+	if errs[1] != nil || results[1].Stdout != "own\n" {
+		t.Errorf("Expected the command's own timeout to win, got %+v %v", results[1], errs[1])
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, errs = NewBatch().Add("echo", "x").Context(ctx).Run()
+	if !errors.Is(errs[0], context.Canceled) {
+		t.Errorf("Expected context.Canceled for a cancelled batch, got %v", errs[0])
+	}
+}
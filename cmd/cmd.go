@@ -16,17 +16,14 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"os"
-	"os/exec"
-	"strings"
 	"time"
 )
 
 // Exec executes a shell command with the given arguments and returns its combined output.
 //
-// The function takes a shell command name and variable arguments, then executes
-// the command using os/exec.Command. It captures both stdout and stderr output
-// using CombinedOutput().
+// The function takes a shell command name and variable arguments, then starts
+// the command with DefaultExecutor, which runs it with os/exec unless replaced.
+// It captures both stdout and stderr output, interleaved as they were written.
 //
 // Parameters:
 //   - shell: The name of the command to execute (e.g., "ls", "echo", "bash")
@@ -41,7 +38,8 @@ import (
 //   - I/O errors during output capture
 //
 // Note: Even if the command fails (returns non-zero exit code), this function
-// will still return the output along with the error. This allows callers to
+// will still return the output along with the error, an *ExitError carrying the
+// exit code and the tail of the output. This allows callers to
 // examine both the error and any output the command may have produced.
 //
 // Example usage:
@@ -74,16 +72,39 @@ import (
 //	}
 //	fmt.Printf("Current user: %s", strings.TrimSpace(output))
 func Exec(shell string, args ...string) (string, error) {
-	cmd := exec.Command(shell, args...)
-	bs, err := cmd.CombinedOutput()
-	if err != nil {
-		return string(bs), err
-	}
-	return string(bs), nil
+	return combinedOutput(NewCommand(shell, args...))
+}
+
+// ExecBytes executes a shell command and returns its combined output as bytes,
+// for commands writing binary data.
+//
+// Parameters:
+//   - shell: The name of the command to execute
+//   - args: Variable number of string arguments to pass to the command
+//
+// Returns:
+//   - []byte: The combined output (stdout + stderr) from the command execution
+//   - error: Any error that occurred during command execution, like Exec
+//
+// Example:
+//
+//	png, err := cmd.ExecBytes("convert", "logo.svg", "png:-")
+//	if err != nil {
+//		log.Printf("Command failed: %v", err)
+//	}
+func ExecBytes(shell string, args ...string) ([]byte, error) {
+	captured, err := NewCommand(shell, args...).CombinedOutput().Capture()
+	output, _ := captured.Output.Bytes() // kept in memory without SpillOver
+	return output, err
 }
 
 // ExecWithTimeout executes a shell command with a timeout and returns its combined output.
-// If the command takes longer than the specified timeout, it will be killed and an error returned.
+// If the command takes longer than the specified timeout, it is stopped and an error
+// wrapping context.DeadlineExceeded is returned.
+//
+// The command runs in its own process group. On timeout the group is sent SIGTERM,
+// then SIGKILL after DefaultGracePeriod, so processes spawned by the command are
+// stopped too.
 //
 // Parameters:
 //   - timeout: The maximum time to wait for the command to complete
@@ -103,13 +124,7 @@ func Exec(shell string, args ...string) (string, error) {
 func ExecWithTimeout(timeout time.Duration, shell string, args ...string) (string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
-
-	cmd := exec.CommandContext(ctx, shell, args...)
-	bs, err := cmd.CombinedOutput()
-	if err != nil {
-		return string(bs), err
-	}
-	return string(bs), nil
+	return combinedOutput(NewCommand(shell, args...).Context(ctx))
 }
 
 // ExecWithDir executes a shell command in the specified working directory.
@@ -130,13 +145,7 @@ func ExecWithTimeout(timeout time.Duration, shell string, args ...string) (strin
 //		log.Printf("Command failed: %v", err)
 //	}
 func ExecWithDir(dir string, shell string, args ...string) (string, error) {
-	cmd := exec.Command(shell, args...)
-	cmd.Dir = dir
-	bs, err := cmd.CombinedOutput()
-	if err != nil {
-		return string(bs), err
-	}
-	return string(bs), nil
+	return combinedOutput(NewCommand(shell, args...).Dir(dir))
 }
 
 // ExecWithEnv executes a shell command with custom environment variables.
@@ -161,18 +170,7 @@ func ExecWithDir(dir string, shell string, args ...string) (string, error) {
 //		log.Printf("Command failed: %v", err)
 //	}
 func ExecWithEnv(env map[string]string, shell string, args ...string) (string, error) {
-	cmd := exec.Command(shell, args...)
-
-	// Set environment variables
-	for key, value := range env {
-		cmd.Env = append(os.Environ(), fmt.Sprintf("%s=%s", key, value))
-	}
-
-	bs, err := cmd.CombinedOutput()
-	if err != nil {
-		return string(bs), err
-	}
-	return string(bs), nil
+	return combinedOutput(NewCommand(shell, args...).Envs(env))
 }
 
 // ExecSeparate executes a shell command and returns stdout and stderr separately.
@@ -195,37 +193,8 @@ func ExecWithEnv(env map[string]string, shell string, args ...string) (string, e
 //		log.Printf("Stderr: %s", stderr)
 //	}
 func ExecSeparate(shell string, args ...string) (string, string, error) {
-	cmd := exec.Command(shell, args...)
-
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return "", "", err
-	}
-
-	stderr, err := cmd.StderrPipe()
-	if err != nil {
-		return "", "", err
-	}
-
-	if err := cmd.Start(); err != nil {
-		return "", "", err
-	}
-
-	stdoutBytes, err := io.ReadAll(stdout)
-	if err != nil {
-		return "", "", err
-	}
-
-	stderrBytes, err := io.ReadAll(stderr)
-	if err != nil {
-		return "", "", err
-	}
-
-	if err := cmd.Wait(); err != nil {
-		return string(stdoutBytes), string(stderrBytes), err
-	}
-
-	return string(stdoutBytes), string(stderrBytes), nil
+	res, err := NewCommand(shell, args...).Run()
+	return res.Stdout, res.Stderr, err
 }
 
 // ExecStream executes a shell command and streams its output to the provided writer.
@@ -247,15 +216,18 @@ func ExecSeparate(shell string, args ...string) (string, string, error) {
 //	}
 //	fmt.Println(buf.String()) // Output: Hello World
 func ExecStream(writer io.Writer, shell string, args ...string) error {
-	cmd := exec.Command(shell, args...)
-	cmd.Stdout = writer
-	cmd.Stderr = writer
-
-	return cmd.Run()
+	spec := &Spec{Name: shell, Args: args, Stdout: writer, Stderr: writer}
+	start := time.Now()
+	exit, err := execute(context.Background(), nil, spec)
+	return newExitError(spec.String(), exit, err, nil, time.Since(start))
 }
 
 // ExecWithContext executes a shell command with a context for cancellation.
 //
+// The command runs in its own process group. When ctx is done the group is sent
+// SIGTERM, then SIGKILL after DefaultGracePeriod, and the returned error wraps
+// the error of ctx.
+//
 // Parameters:
 //   - ctx: The context for cancellation
 //   - shell: The name of the command to execute
@@ -275,12 +247,7 @@ func ExecStream(writer io.Writer, shell string, args ...string) error {
 //		log.Printf("Command failed: %v", err)
 //	}
 func ExecWithContext(ctx context.Context, shell string, args ...string) (string, error) {
-	cmd := exec.CommandContext(ctx, shell, args...)
-	bs, err := cmd.CombinedOutput()
-	if err != nil {
-		return string(bs), err
-	}
-	return string(bs), nil
+	return combinedOutput(NewCommand(shell, args...).Context(ctx))
 }
 
 // ExecWithRetry executes a shell command with retry mechanism.
@@ -485,42 +452,19 @@ func ExecBatch(commands []struct {
 //		log.Printf("Piped command failed: %v", err)
 //	}
 func ExecWithPipe(firstShell string, firstArgs []string, secondShell string, secondArgs []string) (string, error) {
-	firstCmd := exec.Command(firstShell, firstArgs...)
-	secondCmd := exec.Command(secondShell, secondArgs...)
-
-	// Create a pipe between the commands
-	pipe, err := firstCmd.StdoutPipe()
+	res, err := NewPipeline().
+		Pipe(firstShell, firstArgs...).
+		Pipe(secondShell, secondArgs...).
+		Pipefail().
+		Run()
 	if err != nil {
 		return "", err
 	}
+	return res.Stdout, nil
+}
 
-	secondCmd.Stdin = pipe
-	secondCmd.Stdout = &strings.Builder{}
-
-	// Start the second command
-	if err := secondCmd.Start(); err != nil {
-		return "", err
-	}
-
-	// Start the first command
-	if err := firstCmd.Start(); err != nil {
-		return "", err
-	}
-
-	// Wait for the first command to complete
-	if err := firstCmd.Wait(); err != nil {
-		return "", err
-	}
-
-	// Close the pipe
-	_ = pipe.Close()
-
-	// Wait for the second command to complete
-	if err := secondCmd.Wait(); err != nil {
-		return "", err
-	}
-
-	// Get the output from the second command
-	output := secondCmd.Stdout.(*strings.Builder).String()
-	return output, nil
+// combinedOutput runs c and returns its interleaved stdout and stderr.
+func combinedOutput(c *Command) (string, error) {
+	res, err := c.CombinedOutput().Run()
+	return res.Stdout, err
 }
@@ -0,0 +1,479 @@
+// Package cmdtest provides utilities for testing code built on cmd without
+// running real binaries.
+//
+// FakeExecutor is a scripted cmd.Executor: rules match commands on their name,
+// arguments, directory, environment and stdin and reply with canned stdout,
+// stderr and exit codes, optionally after a delay, and every command is recorded
+// for assertions. Install it as cmd.DefaultExecutor for the duration of a test,
+// or set it on a single Command with Command.Executor.
+//
+// Example:
+//
+//	fake := cmdtest.NewFakeExecutor()
+//	fake.On("git", "rev-parse", "HEAD").Reply(0, "3f2a9c1\n")
+//	fake.On("git", "push", "...").ReplyStderr("rejected\n").Reply(1, "")
+//	fake.Install(t)
+//
+//	err := release() // runs git through the cmd package
+//
+//	fake.AssertCalled(t, "git", "push", "origin", "main")
+//	fake.AssertExpectations(t)
+package cmdtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/go4x/goal/cmd"
+)
+
+// AnyArgs is a final argument pattern matching any remaining arguments, none
+// included.
+const AnyArgs = "..."
+
+// Call is a command started through a FakeExecutor.
+type Call struct {
+	// Spec describes the command; its Stdin, Stdout and Stderr are nil.
+	Spec *cmd.Spec
+	// Stdin is the standard input of the command.
+	Stdin []byte
+	// Rule is the rule that answered the command, nil if none matched.
+	Rule *Rule
+}
+
+// String returns the command line of the call.
+func (c *Call) String() string {
+	return c.Spec.String()
+}
+
+// FakeExecutor is a cmd.Executor answering commands from registered rules. Rules
+// are matched in registration order; a rule limited with Times stops matching
+// once exhausted. Commands matching no rule fail to start.
+type FakeExecutor struct {
+	mu    sync.Mutex
+	rules []*Rule
+	calls []*Call
+}
+
+// NewFakeExecutor creates a FakeExecutor without rules.
+func NewFakeExecutor() *FakeExecutor {
+	return &FakeExecutor{}
+}
+
+// Install makes the executor cmd.DefaultExecutor until the end of the test.
+// Tests installing an executor must not run in parallel.
+func (f *FakeExecutor) Install(t testing.TB) *FakeExecutor {
+	t.Helper()
+	previous := cmd.DefaultExecutor
+	cmd.DefaultExecutor = f
+	t.Cleanup(func() { cmd.DefaultExecutor = previous })
+	return f
+}
+
+// On registers a rule for commands named name. name and args are patterns in
+// which * matches any text, slashes included, and ? any single character, so
+// "*" matches a whole argument and "--out=*" any value of a flag.
+// Without args the rule matches any arguments; otherwise there must be one
+// argument per pattern, unless the last pattern is AnyArgs.
+func (f *FakeExecutor) On(name string, args ...string) *Rule {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r := &Rule{name: name, args: slices.Clone(args)}
+	f.rules = append(f.rules, r)
+	return r
+}
+
+// Start implements cmd.Executor. It reads the whole stdin of the command to
+// match it, then returns a process replying once waited for.
+func (f *FakeExecutor) Start(ctx context.Context, spec *cmd.Spec) (cmd.Process, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var stdin []byte
+	if spec.Stdin != nil {
+		var err error
+		if stdin, err = io.ReadAll(spec.Stdin); err != nil {
+			return nil, err
+		}
+	}
+	recorded := *spec
+	recorded.Args = slices.Clone(spec.Args)
+	recorded.Env = slices.Clone(spec.Env)
+	recorded.Stdin, recorded.Stdout, recorded.Stderr = nil, nil, nil
+
+	f.mu.Lock()
+	call := &Call{Spec: &recorded, Stdin: stdin}
+	f.calls = append(f.calls, call)
+	for _, r := range f.rules {
+		if r.exhausted() || !r.matches(call) {
+			continue
+		}
+		r.calls++
+		call.Rule = r
+		break
+	}
+	f.mu.Unlock()
+
+	if call.Rule == nil {
+		return nil, fmt.Errorf("cmdtest: no rule matches %s", call)
+	}
+	if call.Rule.err != nil {
+		return nil, call.Rule.err
+	}
+	return &process{ctx: ctx, rule: call.Rule, spec: spec, stdin: stdin}, nil
+}
+
+// Calls returns all commands started so far, in order.
+func (f *FakeExecutor) Calls() []*Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*Call(nil), f.calls...)
+}
+
+// CallCount returns the number of started commands matching name and args,
+// patterns like On.
+func (f *FakeExecutor) CallCount(name string, args ...string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, c := range f.calls {
+		if matchCommand(name, args, c.Spec) {
+			n++
+		}
+	}
+	return n
+}
+
+// Reset removes all rules and recorded calls.
+func (f *FakeExecutor) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rules, f.calls = nil, nil
+}
+
+// AssertCalled fails the test if no command matching name and args was started.
+func (f *FakeExecutor) AssertCalled(t testing.TB, name string, args ...string) bool {
+	t.Helper()
+	if f.CallCount(name, args...) == 0 {
+		t.Errorf("cmdtest: expected a call to %s, got none", pattern(name, args))
+		return false
+	}
+	return true
+}
+
+// AssertNotCalled fails the test if a command matching name and args was started.
+func (f *FakeExecutor) AssertNotCalled(t testing.TB, name string, args ...string) bool {
+	t.Helper()
+	if n := f.CallCount(name, args...); n != 0 {
+		t.Errorf("cmdtest: expected no call to %s, got %d", pattern(name, args), n)
+		return false
+	}
+	return true
+}
+
+// AssertNumberOfCalls fails the test unless exactly n commands matching name
+// and args were started.
+func (f *FakeExecutor) AssertNumberOfCalls(t testing.TB, n int, name string, args ...string) bool {
+	t.Helper()
+	if got := f.CallCount(name, args...); got != n {
+		t.Errorf("cmdtest: expected %d calls to %s, got %d", n, pattern(name, args), got)
+		return false
+	}
+	return true
+}
+
+// AssertExpectations fails the test if a rule limited with Times or Once was not
+// called exactly that many times, or if a command matched no rule.
+func (f *FakeExecutor) AssertExpectations(t testing.TB) bool {
+	t.Helper()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ok := true
+	for _, r := range f.rules {
+		if r.times > 0 && r.calls != r.times {
+			t.Errorf("cmdtest: expected %d calls to %s, got %d", r.times, r, r.calls)
+			ok = false
+		}
+	}
+	for _, c := range f.calls {
+		if c.Rule == nil {
+			t.Errorf("cmdtest: unexpected call to %s", c)
+			ok = false
+		}
+	}
+	return ok
+}
+
+// Rule matches commands and describes how they behave. Its methods return the
+// rule itself so they can be chained. By default a matching command prints
+// nothing and exits with code 0.
+type Rule struct {
+	name     string
+	args     []string
+	dir      *string
+	env      map[string]string
+	matchers []func(*Call) bool
+
+	code   int
+	signal os.Signal
+	stdout string
+	stderr string
+	fn     func(stdin []byte, stdout, stderr io.Writer) int
+	err    error
+	delay  time.Duration
+	times  int
+	calls  int
+}
+
+// WithDir requires the command to run in dir.
+func (r *Rule) WithDir(dir string) *Rule {
+	r.dir = &dir
+	return r
+}
+
+// WithEnv requires the environment variable key of the command to equal value.
+// Commands inheriting the environment are checked against the current process.
+func (r *Rule) WithEnv(key, value string) *Rule {
+	if r.env == nil {
+		r.env = make(map[string]string)
+	}
+	r.env[key] = value
+	return r
+}
+
+// WithStdin requires the standard input of the command to equal stdin.
+func (r *Rule) WithStdin(stdin string) *Rule {
+	return r.Match(func(c *Call) bool { return string(c.Stdin) == stdin })
+}
+
+// Match adds a custom matcher receiving the call.
+func (r *Rule) Match(fn func(call *Call) bool) *Rule {
+	r.matchers = append(r.matchers, fn)
+	return r
+}
+
+// Reply sets the exit code and the standard output of matching commands.
+func (r *Rule) Reply(code int, stdout string) *Rule {
+	r.code = code
+	r.stdout = stdout
+	return r
+}
+
+// ReplyStderr sets the standard error output of matching commands, written after
+// their standard output.
+func (r *Rule) ReplyStderr(stderr string) *Rule {
+	r.stderr = stderr
+	return r
+}
+
+// ReplySignal makes matching commands end as if terminated by sig.
+func (r *Rule) ReplySignal(sig os.Signal) *Rule {
+	r.signal = sig
+	return r
+}
+
+// ReplyFunc makes matching commands call fn with their stdin and output
+// writers, and exit with the code it returns. It replaces Reply and ReplyStderr.
+func (r *Rule) ReplyFunc(fn func(stdin []byte, stdout, stderr io.Writer) int) *Rule {
+	r.fn = fn
+	return r
+}
+
+// ReplyError makes matching commands fail to start with err, like a missing
+// binary does with exec.ErrNotFound.
+func (r *Rule) ReplyError(err error) *Rule {
+	r.err = err
+	return r
+}
+
+// Delay makes matching commands run for d before replying. If the command's
+// context is done first, it ends as if terminated by SIGTERM, without output.
+func (r *Rule) Delay(d time.Duration) *Rule {
+	r.delay = d
+	return r
+}
+
+// Times limits the rule to n matches; AssertExpectations checks it was called
+// exactly n times.
+func (r *Rule) Times(n int) *Rule {
+	r.times = n
+	return r
+}
+
+// Once is shorthand for Times(1).
+func (r *Rule) Once() *Rule {
+	return r.Times(1)
+}
+
+// String describes the rule for failure messages.
+func (r *Rule) String() string {
+	return pattern(r.name, r.args)
+}
+
+// exhausted reports whether the rule reached its Times limit.
+func (r *Rule) exhausted() bool {
+	return r.times > 0 && r.calls >= r.times
+}
+
+// matches reports whether c satisfies every condition of the rule.
+func (r *Rule) matches(c *Call) bool {
+	if !matchCommand(r.name, r.args, c.Spec) {
+		return false
+	}
+	if r.dir != nil && *r.dir != c.Spec.Dir {
+		return false
+	}
+	for k, v := range r.env {
+		if value, ok := lookupEnv(c.Spec.Env, k); !ok || value != v {
+			return false
+		}
+	}
+	for _, fn := range r.matchers {
+		if !fn(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// process is a command started by a FakeExecutor.
+type process struct {
+	ctx   context.Context
+	rule  *Rule
+	spec  *cmd.Spec
+	stdin []byte
+}
+
+func (p *process) Wait() (cmd.Exit, error) {
+	if p.rule.delay > 0 {
+		timer := time.NewTimer(p.rule.delay)
+		defer timer.Stop()
+		select {
+		case <-p.ctx.Done():
+			return p.exit(-1, syscall.SIGTERM, cmd.Terminated)
+		case <-timer.C:
+		}
+	}
+
+	stdout, stderr := writer(p.spec.Stdout), writer(p.spec.Stderr)
+	if p.rule.fn != nil {
+		return p.exit(p.rule.fn(p.stdin, stdout, stderr), nil, cmd.Exited)
+	}
+	if _, err := io.WriteString(stdout, p.rule.stdout); err != nil {
+		return cmd.Exit{Code: -1}, err
+	}
+	if _, err := io.WriteString(stderr, p.rule.stderr); err != nil {
+		return cmd.Exit{Code: -1}, err
+	}
+	if p.rule.signal != nil {
+		return p.exit(-1, p.rule.signal, cmd.Exited)
+	}
+	return p.exit(p.rule.code, nil, cmd.Exited)
+}
+
+// exit reports an exit like os/exec, with an error unless code is 0.
+func (p *process) exit(code int, sig os.Signal, termination cmd.Termination) (cmd.Exit, error) {
+	exit := cmd.Exit{Code: code, Signal: sig, Termination: termination}
+	switch {
+	case sig != nil:
+		return exit, errors.New("signal: " + sig.String())
+	case code != 0:
+		return exit, fmt.Errorf("exit status %d", code)
+	}
+	return exit, nil
+}
+
+// writer returns w, or io.Discard if nil.
+func writer(w io.Writer) io.Writer {
+	if w == nil {
+		return io.Discard
+	}
+	return w
+}
+
+// matchCommand reports whether spec has a name matching name and arguments
+// matching args, patterns like FakeExecutor.On.
+func matchCommand(name string, args []string, spec *cmd.Spec) bool {
+	if !match(name, spec.Name) {
+		return false
+	}
+	if len(args) == 0 {
+		return true
+	}
+	if args[len(args)-1] == AnyArgs {
+		args = args[:len(args)-1]
+		if len(spec.Args) < len(args) {
+			return false
+		}
+	} else if len(spec.Args) != len(args) {
+		return false
+	}
+	for i, p := range args {
+		if !match(p, spec.Args[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// match reports whether s matches pattern, where * matches any text and ? any
+// single character.
+func match(pattern, s string) bool {
+	if pattern == s {
+		return true
+	}
+	return matchRunes([]rune(pattern), []rune(s))
+}
+
+func matchRunes(pattern, s []rune) bool {
+	star, next := -1, 0 // position after the last *, and where it resumes in s
+	for p, i := 0, 0; i < len(s) || p < len(pattern); {
+		if p < len(pattern) {
+			switch c := pattern[p]; {
+			case c == '*':
+				star, next = p+1, i
+				p++
+				continue
+			case i < len(s) && (c == '?' || c == s[i]):
+				p++
+				i++
+				continue
+			}
+		}
+		if star < 0 || next >= len(s) {
+			return false
+		}
+		// Let the last * match one more character and retry from there.
+		next++
+		p, i = star, next
+	}
+	return true
+}
+
+// lookupEnv returns the value of key in env, the environment of the current
+// process if env is nil. Like os/exec, the last value of a duplicate key wins.
+func lookupEnv(env []string, key string) (string, bool) {
+	if env == nil {
+		return os.LookupEnv(key)
+	}
+	for i := len(env) - 1; i >= 0; i-- {
+		if k, v, ok := strings.Cut(env[i], "="); ok && k == key {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// pattern formats a command pattern for failure messages.
+func pattern(name string, args []string) string {
+	return strings.TrimSpace(name + " " + strings.Join(args, " "))
+}
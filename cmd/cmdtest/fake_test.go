@@ -0,0 +1,184 @@
+package cmdtest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/go4x/goal/cmd"
+)
+
+func TestFakeExecutor_Rules(t *testing.T) {
+	fake := NewFakeExecutor().Install(t)
+	fake.On("git", "rev-parse", "HEAD").Reply(0, "3f2a9c1\n")
+	fake.On("git", "push", AnyArgs).ReplyStderr("rejected\n").Reply(1, "pushing\n").Once()
+	fake.On("ls", "--color=*", "*").WithDir("/srv").Reply(0, "a\nb\n")
+	fake.On("env").WithEnv("MODE", "test").Reply(0, "ok")
+	fake.On("kubectl").ReplyError(exec.ErrNotFound)
+
+	if out, err := cmd.Exec("git", "rev-parse", "HEAD"); err != nil || out != "3f2a9c1\n" {
+		t.Errorf("Unexpected output %q %v", out, err)
+	}
+
+	stdout, stderr, err := cmd.ExecSeparate("git", "push", "origin", "main")
+	var exitErr *cmd.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode != 1 || exitErr.Stderr != "rejected\n" {
+		t.Fatalf("Expected an exit error with code 1, got %v", err)
+	}
+	if err.Error() != "git push origin main: exit status 1: rejected" || stdout != "pushing\n" || stderr != "rejected\n" {
+		t.Errorf("Unexpected result %q %q %v", stdout, stderr, err)
+	}
+	var execErr *exec.ExitError
+	if errors.As(err, &execErr) {
+		t.Error("A fake exit should not wrap an *exec.ExitError")
+	}
+	if _, err := cmd.Exec("git", "push"); err == nil || !strings.Contains(err.Error(), "no rule matches git push") {
+		t.Errorf("Rule limited with Once should not match twice, got %v", err)
+	}
+
+	if _, err := cmd.Exec("ls", "--color=auto", "/tmp"); err == nil {
+		t.Error("Expected no match outside the rule directory")
+	}
+	if out, err := cmd.ExecWithDir("/srv", "ls", "--color=auto", "/tmp"); err != nil || out != "a\nb\n" {
+		t.Errorf("Unexpected output %q %v", out, err)
+	}
+	if out, err := cmd.ExecWithEnv(map[string]string{"MODE": "test"}, "env"); err != nil || out != "ok" {
+		t.Errorf("Unexpected output %q %v", out, err)
+	}
+	if _, err := cmd.Exec("kubectl", "apply"); !errors.Is(err, exec.ErrNotFound) {
+		t.Errorf("Expected exec.ErrNotFound, got %v", err)
+	}
+
+	fake.AssertCalled(t, "git", "push", "origin", "*")
+	fake.AssertNumberOfCalls(t, 2, "git", "push", AnyArgs)
+	fake.AssertNotCalled(t, "rm")
+	calls := fake.Calls()
+	if len(calls) != 7 {
+		t.Fatalf("Expected 7 recorded calls, got %d", len(calls))
+	}
+	if calls[4].Spec.Dir != "/srv" || calls[4].Rule == nil || calls[4].String() != "ls --color=auto /tmp" {
+		t.Errorf("Unexpected recorded call %+v", calls[4].Spec)
+	}
+
+	if !match("v?.*-rc*", "v1.24.0-rc1") || !match("*/bin/*", "/usr/bin/git") || match("a*b", "ab/c") || !match("日?", "日本") {
+		t.Error("Unexpected pattern matching")
+	}
+
+	mockT := &testing.T{}
+	if fake.AssertExpectations(mockT) {
+		t.Error("AssertExpectations should fail with unmatched calls")
+	}
+	fake.Reset()
+	if len(fake.Calls()) != 0 || !fake.AssertExpectations(t) {
+		t.Error("Reset should clear rules and calls")
+	}
+}
+
+func TestFakeExecutor_Command(t *testing.T) {
+	fake := NewFakeExecutor()
+	fake.On("grep", "-c", "x").WithStdin("x\ny\nx\n").Reply(0, "2\n")
+	fake.On("sh").ReplyFunc(func(stdin []byte, stdout, stderr io.Writer) int {
+		_, _ = io.WriteString(stdout, "line 1\nline 2\n")
+		_, _ = io.WriteString(stderr, "warning\n")
+		return 3
+	})
+	fake.On("crash").ReplySignal(syscall.SIGKILL)
+
+	res, err := cmd.NewCommand("grep", "-c", "x").StdinString("x\ny\nx\n").Executor(fake).Run()
+	if err != nil || res.Stdout != "2\n" || string(fake.Calls()[0].Stdin) != "x\ny\nx\n" {
+		t.Errorf("Unexpected result %+v %v", res, err)
+	}
+
+	// Each stream is read by its own goroutine, so only the order within a
+	// stream is deterministic.
+	lines := map[cmd.Stream][]string{}
+	res, err = cmd.NewCommand("sh", "-c", "build").Executor(fake).StreamLines(func(l cmd.Line) {
+		lines[l.Stream] = append(lines[l.Stream], l.Text)
+	})
+	if res.ExitCode != 3 || err == nil {
+		t.Errorf("Expected exit code 3, got %+v %v", res, err)
+	}
+	if strings.Join(lines[cmd.Stdout], ",") != "line 1,line 2" || strings.Join(lines[cmd.Stderr], ",") != "warning" {
+		t.Errorf("Unexpected lines %q", lines)
+	}
+
+	res, err = cmd.NewCommand("crash").Executor(fake).Run()
+	var exitErr *cmd.ExitError
+	if !errors.As(err, &exitErr) || exitErr.Signal != syscall.SIGKILL || res.ExitCode != -1 {
+		t.Errorf("Expected a signal exit, got %+v %v", res, err)
+	}
+	if err.Error() != "crash: signal: killed" {
+		t.Errorf("Unexpected message %q", err)
+	}
+	if cmd.DefaultExecutor != (cmd.OSExecutor{}) {
+		t.Error("Command.Executor should not change the default executor")
+	}
+}
+
+func TestFakeExecutor_Delay(t *testing.T) {
+	fake := NewFakeExecutor().Install(t)
+	fake.On("sleep").Delay(5*time.Second).Reply(0, "late")
+	fake.On("sort").Delay(50*time.Millisecond).Reply(0, "a\nb\n")
+
+	start := time.Now()
+	out, err := cmd.ExecWithTimeout(50*time.Millisecond, "sleep", "10")
+	if !errors.Is(err, context.DeadlineExceeded) || out != "" {
+		t.Errorf("Expected context.DeadlineExceeded, got %q %v", out, err)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("Expected the delay to stop with the context, took %s", time.Since(start))
+	}
+	res, _ := cmd.NewCommand("sleep", "10").Timeout(20 * time.Millisecond).Run()
+	if res.Termination != cmd.Terminated || res.ExitCode != -1 {
+		t.Errorf("Expected a terminated command, got %+v", res)
+	}
+
+	start = time.Now()
+	out, err = cmd.ExecWithTimeout(time.Second, "sort")
+	if err != nil || out != "a\nb\n" || time.Since(start) < 50*time.Millisecond {
+		t.Errorf("Expected the reply after the delay, got %q %v in %s", out, err, time.Since(start))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := cmd.ExecWithContext(ctx, "sort"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestFakeExecutor_Pipeline(t *testing.T) {
+	fake := NewFakeExecutor().Install(t)
+	fake.On("cat", "app.log").Reply(0, strings.Repeat("error\ninfo\n", 50000))
+	fake.On("grep", "error").ReplyFunc(func(stdin []byte, stdout, _ io.Writer) int {
+		for _, line := range strings.Split(string(stdin), "\n") {
+			if line == "error" {
+				_, _ = io.WriteString(stdout, line+"\n")
+			}
+		}
+		return 0
+	})
+	fake.On("wc", "-l").ReplyFunc(func(stdin []byte, stdout, _ io.Writer) int {
+		_, _ = io.WriteString(stdout, strings.Repeat("x", bytes.Count(stdin, []byte("\n"))))
+		return 0
+	})
+
+	res, err := cmd.NewPipeline().Pipe("cat", "app.log").Pipe("grep", "error").Pipe("wc", "-l").Run()
+	if err != nil || len(res.Stdout) != 50000 {
+		t.Fatalf("Unexpected pipeline result %d %v", len(res.Stdout), err)
+	}
+	if out, err := cmd.ExecWithPipe("cat", []string{"app.log"}, "grep", []string{"error"}); err != nil || len(out) != 6*50000 {
+		t.Errorf("Unexpected piped output %d %v", len(out), err)
+	}
+
+	results, errs := cmd.NewBatch().Add("cat", "app.log").Add("missing").Run()
+	if errs[0] != nil || results[0].ExitCode != 0 || errs[1] == nil {
+		t.Errorf("Unexpected batch results %v", errs)
+	}
+	fake.AssertNumberOfCalls(t, 3, "cat", "app.log")
+}
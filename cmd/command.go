@@ -0,0 +1,327 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/go4x/goal/retry"
+)
+
+// Command is a fluent builder for running a command with several options at once,
+// such as a working directory, environment, stdin, timeout and retries.
+//
+// The builder methods modify and return the Command, so calls can be chained.
+// A Command can be run several times; it must not be modified while running.
+//
+// Example:
+//
+//	res, err := cmd.NewCommand("git", "status", "--short").
+//		Dir("/srv/repo").
+//		Env("GIT_PAGER", "cat").
+//		Timeout(10 * time.Second).
+//		Run()
+//	if err != nil {
+//		log.Printf("%s failed with exit code %d: %s", res.Command, res.ExitCode, res.Stderr)
+//	}
+type Command struct {
+	name       string
+	args       []string
+	dir        string
+	env        []string
+	replaceEnv bool
+	stdin      io.Reader
+	stdinData  *string
+	ctx        context.Context
+	timeout    time.Duration
+	grace      time.Duration
+	combined   bool
+	tail       *int
+	retries    uint
+	interval   retry.Intervaler
+	executor   Executor
+	maxOutput  int64
+	overflow   Overflow
+	spill      int64
+}
+
+// Result is the outcome of running a Command.
+type Result struct {
+	// Command is the resolved command line, quoted for display.
+	Command string
+	// Stdout is the standard output, or the interleaved stdout and stderr when
+	// CombinedOutput is set.
+	Stdout string
+	// Stderr is the standard error output, empty when CombinedOutput is set.
+	Stderr string
+	// ExitCode is the exit code of the last attempt, or -1 if the command could
+	// not be started or was terminated by a signal.
+	ExitCode int
+	// Duration is the wall time of the last attempt.
+	Duration time.Duration
+	// Termination tells whether the last attempt exited on its own or was
+	// stopped by its context or timeout.
+	Termination Termination
+	// Attempts is the number of times the command was run.
+	Attempts int
+	// Tail holds the last output lines of commands run with StreamLines or
+	// Lines, oldest first.
+	Tail []Line
+	// Truncated reports whether output beyond the MaxOutput limit was discarded.
+	Truncated bool
+}
+
+// Success reports whether the command exited with code 0.
+func (r *Result) Success() bool {
+	return r.ExitCode == 0
+}
+
+// NewCommand creates a Command running name with the given arguments.
+//
+// By default the command inherits the environment of the current process,
+// reads no stdin, captures stdout and stderr separately and runs once without
+// a timeout. When stopped by its context or timeout, its process group gets
+// DefaultGracePeriod to exit after SIGTERM.
+func NewCommand(name string, args ...string) *Command {
+	return &Command{name: name, args: slices.Clone(args), grace: DefaultGracePeriod}
+}
+
+// Args appends arguments to the command.
+func (c *Command) Args(args ...string) *Command {
+	c.args = append(c.args, args...)
+	return c
+}
+
+// Dir sets the working directory of the command.
+func (c *Command) Dir(dir string) *Command {
+	c.dir = dir
+	return c
+}
+
+// Env sets an environment variable, merged over the inherited environment
+// unless ReplaceEnv is used.
+func (c *Command) Env(key, value string) *Command {
+	c.env = append(c.env, key+"="+value)
+	return c
+}
+
+// Envs sets several environment variables like Env.
+func (c *Command) Envs(env map[string]string) *Command {
+	for key, value := range env {
+		c.Env(key, value)
+	}
+	return c
+}
+
+// ReplaceEnv makes the command run with only the given variables instead of the
+// inherited environment. Variables set later with Env are added to them.
+func (c *Command) ReplaceEnv(env map[string]string) *Command {
+	c.replaceEnv = true
+	c.env = nil
+	return c.Envs(env)
+}
+
+// Stdin sets the standard input of the command. When the command is retried,
+// the reader is read into memory so every attempt sees the same input.
+func (c *Command) Stdin(r io.Reader) *Command {
+	c.stdin = r
+	c.stdinData = nil
+	return c
+}
+
+// StdinString sets the standard input of the command to s.
+func (c *Command) StdinString(s string) *Command {
+	c.stdin = nil
+	c.stdinData = &s
+	return c
+}
+
+// Context sets a context that stops the command when done.
+func (c *Command) Context(ctx context.Context) *Command {
+	c.ctx = ctx
+	return c
+}
+
+// Timeout limits the duration of each attempt. Zero means no timeout.
+func (c *Command) Timeout(timeout time.Duration) *Command {
+	c.timeout = timeout
+	return c
+}
+
+// GracePeriod sets how long the command may take to exit after SIGTERM when
+// stopped by its context or timeout, before its process group is killed.
+// Zero kills it right away.
+func (c *Command) GracePeriod(grace time.Duration) *Command {
+	c.grace = grace
+	return c
+}
+
+// CombinedOutput captures stdout and stderr interleaved into Result.Stdout,
+// like Exec.
+func (c *Command) CombinedOutput() *Command {
+	c.combined = true
+	return c
+}
+
+// Retry reruns the failed command up to times more times, waiting between
+// attempts according to interval, retry.DefaultInterval() if nil.
+// Commands stopped by their context are not retried.
+func (c *Command) Retry(times uint, interval retry.Intervaler) *Command {
+	c.retries = times
+	c.interval = interval
+	return c
+}
+
+// Executor sets the Executor starting the command, DefaultExecutor by default.
+func (c *Command) Executor(e Executor) *Command {
+	c.executor = e
+	return c
+}
+
+// String returns the command line, quoted for display.
+func (c *Command) String() string {
+	return commandLine(c.name, c.args)
+}
+
+// Run runs the command and returns its result. The result is returned even if
+// the command fails, so the output and exit code of failures can be inspected.
+// Failed exits are reported as *ExitError.
+func (c *Command) Run() (*Result, error) {
+	captured, err := c.runWith(c.baseContext(), c.timeout, false)
+	return captured.Result, err
+}
+
+// baseContext returns the context set with Context, or context.Background().
+func (c *Command) baseContext() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+// runWith runs the command under ctx, limiting each attempt to timeout.
+func (c *Command) runWith(ctx context.Context, timeout time.Duration, capture bool) (*Captured, error) {
+	var stdinData []byte
+	switch {
+	case c.stdinData != nil:
+		stdinData = []byte(*c.stdinData)
+	case c.stdin != nil && c.retries > 0:
+		data, err := io.ReadAll(c.stdin)
+		if err != nil {
+			return &Captured{
+				Result:    &Result{Command: c.String(), ExitCode: -1},
+				Output:    &Output{},
+				ErrOutput: &Output{},
+			}, err
+		}
+		stdinData = data
+	}
+
+	var captured *Captured
+	var err error
+	if c.retries == 0 {
+		captured, err = c.run(ctx, timeout, stdinData, capture)
+		return captured, err
+	}
+	interval := c.interval
+	if interval == nil {
+		interval = retry.DefaultInterval()
+	}
+	attempts := 0
+	_ = retry.Do(func() (bool, error) {
+		if captured != nil {
+			_ = captured.Close()
+		}
+		attempts++
+		captured, err = c.run(ctx, timeout, stdinData, capture)
+		return ctx.Err() != nil || errors.Is(err, ErrOutputLimit), err
+	}, retry.Times(c.retries), retry.Interval(interval))
+	captured.Attempts = attempts
+	return captured, err
+}
+
+// run runs the command once. Unless capture is set, the output is also stored in
+// Result.Stdout and Result.Stderr and never spilled to files.
+func (c *Command) run(ctx context.Context, timeout time.Duration, stdinData []byte, capture bool) (*Captured, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	// Cancelled to stop the command when its output exceeds the limit.
+	runCtx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	spec := c.spec()
+	if stdinData != nil {
+		spec.Stdin = bytes.NewReader(stdinData)
+	} else {
+		spec.Stdin = c.stdin
+	}
+	stdout := &Output{limit: c.maxOutput, overflow: c.overflow, onOverflow: stop}
+	stderr := &Output{limit: c.maxOutput, overflow: c.overflow, onOverflow: stop}
+	if capture {
+		stdout.threshold, stderr.threshold = c.spill, c.spill
+	}
+	spec.Stdout = outputWriter{stdout}
+	if c.combined {
+		spec.Stderr = outputWriter{stdout}
+	} else {
+		spec.Stderr = outputWriter{stderr}
+	}
+
+	start := time.Now()
+	exit, err := execute(runCtx, c.executor, spec)
+	res := &Result{
+		Command:     c.String(),
+		ExitCode:    exit.Code,
+		Duration:    time.Since(start),
+		Termination: exit.Termination,
+		Attempts:    1,
+		Truncated:   stdout.truncated || stderr.truncated,
+	}
+	if !capture {
+		res.Stdout, res.Stderr = stdout.String(), stderr.String()
+	}
+	captured := &Captured{Result: res, Output: stdout, ErrOutput: stderr}
+	if finishErr := errors.Join(stdout.finish(), stderr.finish()); finishErr != nil && err == nil {
+		err = finishErr
+	}
+
+	errOutput := stderr
+	if c.combined {
+		errOutput = stdout
+	}
+	if c.overflow == OverflowFail && res.Truncated && ctx.Err() == nil {
+		return captured, fmt.Errorf("%s: %w (%d bytes)", res.Command, ErrOutputLimit, c.maxOutput)
+	}
+	return captured, contextError(ctx, newExitError(res.Command, exit, err, errOutput.tail(maxStderrTail), res.Duration))
+}
+
+// spec describes the command for its Executor, without stdin and output.
+func (c *Command) spec() *Spec {
+	return &Spec{Name: c.name, Args: c.args, Dir: c.dir, Env: c.environ(), Grace: c.grace}
+}
+
+// environ returns the environment of the command, nil to inherit it unchanged.
+func (c *Command) environ() []string {
+	if !c.replaceEnv && len(c.env) == 0 {
+		return nil
+	}
+	env := []string{}
+	if !c.replaceEnv {
+		env = os.Environ()
+	}
+	// Later values win; exec.Cmd keeps the last value of duplicate keys.
+	return append(env, c.env...)
+}
+
+// commandLine joins name and args into a command line quoted with Join.
+func commandLine(name string, args []string) string {
+	return Join(append([]string{name}, args...))
+}
@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go4x/goal/retry"
+)
+
+// TestCommand_Run 测试组合多个选项执行命令
+func TestCommand_Run(t *testing.T) {
+	dir := t.TempDir()
+	res, err := NewCommand("bash", "-c", `pwd; echo "$GOAL_A-$GOAL_B"; cat; echo oops >&2; exit 3`).
+		Dir(dir).
+		Env("GOAL_A", "a").
+		Envs(map[string]string{"GOAL_B": "b"}).
+		StdinString("from stdin\n").
+		Timeout(5 * time.Second).
+		Run()
+	if err == nil {
+		t.Fatal("Expected an error for exit code 3")
+	}
+	resolved, _ := filepath.EvalSymlinks(dir)
+	want := resolved + "\na-b\nfrom stdin\n"
+	if res.Stdout != want && res.Stdout != dir+"\na-b\nfrom stdin\n" {
+		t.Errorf("Stdout = %q, want %q", res.Stdout, want)
+	}
+	if res.Stderr != "oops\n" || res.ExitCode != 3 || res.Success() || res.Attempts != 1 || res.Duration <= 0 {
+		t.Errorf("Unexpected result %+v", res)
+	}
+	if !strings.HasPrefix(res.Command, "bash -c 'pwd; echo ") {
+		t.Errorf("Unexpected command line %q", res.Command)
+	}
+}
+
+// TestCommand_Env 测试继承、合并与替换环境变量
+func TestCommand_Env(t *testing.T) {
+	t.Setenv("GOAL_INHERITED", "parent")
+
+	res, err := NewCommand("sh", "-c", `echo "$GOAL_INHERITED"`).Run()
+	if err != nil || res.Stdout != "parent\n" {
+		t.Errorf("Expected the inherited environment, got %q %v", res.Stdout, err)
+	}
+
+	res, err = NewCommand("sh", "-c", `echo "$GOAL_INHERITED"`).Env("GOAL_INHERITED", "child").Run()
+	if err != nil || res.Stdout != "child\n" {
+		t.Errorf("Expected merged variables to override inherited ones, got %q %v", res.Stdout, err)
+	}
+
+	res, err = NewCommand("/usr/bin/env").ReplaceEnv(map[string]string{"ONLY": "1"}).Run()
+	if err != nil || res.Stdout != "ONLY=1\n" {
+		t.Errorf("Expected only the replaced environment, got %q %v", res.Stdout, err)
+	}
+
+	res, err = NewCommand("/usr/bin/env").ReplaceEnv(nil).Run()
+	if err != nil || res.Stdout != "" {
+		t.Errorf("Expected an empty environment, got %q %v", res.Stdout, err)
+	}
+}
+
+// TestCommand_CombinedOutput 测试合并输出
+func TestCommand_CombinedOutput(t *testing.T) {
+	res, err := NewCommand("sh", "-c", "echo out; echo err >&2").CombinedOutput().Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Stdout != "out\nerr\n" || res.Stderr != "" {
+		t.Errorf("Unexpected combined output %q %q", res.Stdout, res.Stderr)
+	}
+}
+
+// TestCommand_Timeout 测试超时与上下文取消
+func TestCommand_Timeout(t *testing.T) {
+	start := time.Now()
+	res, err := NewCommand("sleep", "5").Timeout(100 * time.Millisecond).Run()
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if res.ExitCode != -1 || time.Since(start) > 3*time.Second {
+		t.Errorf("Expected the command to be killed, got %+v", res)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = NewCommand("sleep", "5").Context(ctx).Retry(3, retry.ConstantInterval(time.Millisecond)).Run()
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+// TestCommand_Retry 测试重试与标准输入重放
+func TestCommand_Retry(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "marker")
+	script := `read line; if [ -f "$0" ]; then echo "$line"; else touch "$0"; exit 1; fi`
+	res, err := NewCommand("sh", "-c", script, marker).
+		Stdin(strings.NewReader("payload\n")).
+		Retry(2, retry.ConstantInterval(time.Millisecond)).
+		Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Attempts != 2 || res.Stdout != "payload\n" {
+		t.Errorf("Expected success on the second attempt with the same stdin, got %+v", res)
+	}
+
+	res, err = NewCommand("false").Retry(2, retry.ConstantInterval(time.Millisecond)).Run()
+	if err == nil || res.Attempts != 3 || res.ExitCode != 1 {
+		t.Errorf("Expected 3 failed attempts, got %+v %v", res, err)
+	}
+}
+
+// TestCommand_NotFound 测试命令不存在
+func TestCommand_NotFound(t *testing.T) {
+	res, err := NewCommand("nonexistentcommand12345").Run()
+	if err == nil || res.ExitCode != -1 {
+		t.Errorf("Expected a start error, got %+v %v", res, err)
+	}
+}
+
+// TestCommand_String 测试命令行引用
+func TestCommand_String(t *testing.T) {
+	args := []string{"a", "b"}
+	c := NewCommand("echo", args...).Args("hello world", "it's", "", "--flag=x")
+	if got := c.String(); got != `echo a b 'hello world' 'it'\''s' '' --flag=x` {
+		t.Errorf("String() = %s", got)
+	}
+	res, _ := NewCommand("sh", "-c", c.String()).Run()
+	if res.Stdout != "a b hello world it's  --flag=x\n" {
+		t.Errorf("Quoted command line did not round trip: %q", res.Stdout)
+	}
+}
@@ -3,11 +3,13 @@ package cmd_test
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/go4x/goal/cmd"
+	"github.com/go4x/goal/retry"
 )
 
 // ExampleExec demonstrates basic command execution
@@ -29,7 +31,7 @@ func ExampleExecWithTimeout() {
 		fmt.Printf("Command timed out: %v\n", err)
 	}
 	fmt.Printf("Output: %s", output)
-	// Output: Command timed out: signal: killed
+	// Output: Command timed out: context deadline exceeded: sleep 5: signal: terminated
 	// Output:
 }
 
@@ -100,7 +102,7 @@ func ExampleExecWithContext() {
 		fmt.Printf("Command cancelled: %v\n", err)
 	}
 	fmt.Printf("Output: %s", output)
-	// Output: Command cancelled: signal: killed
+	// Output: Command cancelled: context canceled: sleep 5: signal: terminated
 	// Output:
 }
 
@@ -202,3 +204,94 @@ func ExampleExecWithPipe() {
 	fmt.Print(output)
 	// Output: line2
 }
+
+// ExampleCommand demonstrates combining several options with the Command builder
+func ExampleCommand() {
+	res, err := cmd.NewCommand("sh", "-c", `echo "$GREETING, $(cat)"; echo done >&2`).
+		Dir("/tmp").
+		Env("GREETING", "Hello").
+		StdinString("World").
+		Timeout(5 * time.Second).
+		Run()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	fmt.Printf("Stdout: %s", res.Stdout)
+	fmt.Printf("Stderr: %s", res.Stderr)
+	fmt.Printf("Exit code: %d\n", res.ExitCode)
+	// Output:
+	// Stdout: Hello, World
+	// Stderr: done
+	// Exit code: 0
+}
+
+// ExamplePipeline demonstrates a multi-stage pipeline with pipefail
+func ExamplePipeline() {
+	res, err := cmd.NewPipeline().
+		Pipe("printf", "banana\\napple\\ncherry\\n").
+		Pipe("sort").
+		Pipe("head", "-n", "2").
+		Pipefail().
+		Run()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	fmt.Print(res.Stdout)
+	for _, stage := range res.Stages {
+		fmt.Printf("%s: %d\n", stage.Command, stage.ExitCode)
+	}
+	// Output:
+	// apple
+	// banana
+	// printf 'banana\napple\ncherry\n': 0
+	// sort: 0
+	// head -n 2: 0
+}
+
+// ExampleSplit demonstrates parsing a command line and quoting it back
+func ExampleSplit() {
+	words, err := cmd.Split(`grep -n "hello world" 'notes.txt' $DIR`, func(name string) string {
+		return "/tmp/" + strings.ToLower(name)
+	})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	for _, word := range words {
+		fmt.Println(word)
+	}
+	fmt.Println(cmd.Join(words))
+	// Output:
+	// grep
+	// -n
+	// hello world
+	// notes.txt
+	// /tmp/dir
+	// grep -n 'hello world' notes.txt /tmp/dir
+}
+
+// ExampleSupervisor demonstrates restarting a failing process with backoff
+func ExampleSupervisor() {
+	sup := cmd.NewSupervisor(cmd.NewCommand("sh", "-c", "echo starting; exit 1")).
+		Policy(cmd.RestartOnFailure).
+		Backoff(retry.ConstantInterval(10*time.Millisecond)).
+		MaxRestarts(2, time.Minute).
+		OnLine(func(l cmd.Line) {
+			fmt.Println(l)
+		})
+	if err := sup.Start(); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	err := sup.Wait()
+	fmt.Printf("Restarts: %d\n", sup.Restarts())
+	fmt.Printf("Gave up: %v\n", errors.Is(err, cmd.ErrTooManyRestarts))
+	// Output:
+	// stdout starting
+	// stdout starting
+	// stdout starting
+	// Restarts: 2
+	// Gave up: true
+}
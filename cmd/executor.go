@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// Executor starts the commands run by this package. Every function and type of
+// the package starts its commands through DefaultExecutor, or the Executor set
+// with Command.Executor, so that code built on them can be unit tested without
+// real binaries. Package cmdtest provides a scripted fake.
+type Executor interface {
+	// Start starts the command described by spec. When ctx is done before the
+	// command exits, the command is stopped, giving it spec.Grace to exit.
+	Start(ctx context.Context, spec *Spec) (Process, error)
+}
+
+// Process is a command started by an Executor.
+type Process interface {
+	// Wait waits for the command to exit and for its output to be written, then
+	// reports how it ended. Like exec.Cmd.Wait, the error is nil only if the
+	// command exited with code 0.
+	Wait() (Exit, error)
+}
+
+// Spec describes a command to start.
+type Spec struct {
+	// Name is the program to run, looked up in PATH if it contains no separator.
+	Name string
+	// Args are the arguments, not including the program name.
+	Args []string
+	// Dir is the working directory, the current one if empty.
+	Dir string
+	// Env is the environment, nil to inherit the one of the current process.
+	Env []string
+	// Stdin is the standard input, empty if nil.
+	Stdin io.Reader
+	// Stdout and Stderr receive the output, discarded if nil. When they are the
+	// same writer, at most one goroutine writes to it at a time.
+	Stdout io.Writer
+	Stderr io.Writer
+	// Grace is how long the command may take to exit after SIGTERM when its
+	// context is done, before it is killed.
+	Grace time.Duration
+}
+
+// String returns the command line, quoted for display.
+func (s *Spec) String() string {
+	return commandLine(s.Name, s.Args)
+}
+
+// Exit describes how a command ended.
+type Exit struct {
+	// Code is the exit code, or -1 if the command was terminated by a signal.
+	Code int
+	// Signal is the signal that terminated the command, nil if it exited.
+	Signal os.Signal
+	// Termination tells whether the command was stopped by its context.
+	Termination Termination
+}
+
+// DefaultExecutor is the Executor used by the Exec functions and by Commands
+// without their own Executor. Tests may replace it, but not while commands run.
+//
+// Example:
+//
+//	fake := cmdtest.NewFakeExecutor()
+//	fake.On("git", "rev-parse", "HEAD").Reply(0, "3f2a9c1\n")
+//	cmd.DefaultExecutor = fake
+//	defer func() { cmd.DefaultExecutor = cmd.OSExecutor{} }()
+var DefaultExecutor Executor = OSExecutor{}
+
+// OSExecutor runs commands as processes of the operating system. On Unix each
+// command runs in its own process group, which is stopped as a whole when the
+// context of the command is done.
+type OSExecutor struct{}
+
+// Start implements Executor.
+func (OSExecutor) Start(ctx context.Context, spec *Spec) (Process, error) {
+	cmd, group := commandContext(ctx, spec.Grace, spec.Name, spec.Args...)
+	cmd.Dir = spec.Dir
+	cmd.Env = spec.Env
+	cmd.Stdin = spec.Stdin
+	cmd.Stdout = spec.Stdout
+	cmd.Stderr = spec.Stderr
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &osProcess{cmd: cmd, group: group}, nil
+}
+
+type osProcess struct {
+	cmd   *exec.Cmd
+	group *processGroup
+}
+
+func (p *osProcess) Wait() (Exit, error) {
+	err := p.cmd.Wait()
+	exit := Exit{Code: -1, Termination: p.group.finish()}
+	if state := p.cmd.ProcessState; state != nil {
+		exit.Code = state.ExitCode()
+		if status, ok := state.Sys().(interface {
+			Signaled() bool
+			Signal() syscall.Signal
+		}); ok && status.Signaled() {
+			exit.Signal = status.Signal()
+		}
+	}
+	return exit, err
+}
+
+// execute starts spec with executor, DefaultExecutor if nil, and waits for it.
+func execute(ctx context.Context, executor Executor, spec *Spec) (Exit, error) {
+	if executor == nil {
+		executor = DefaultExecutor
+	}
+	proc, err := executor.Start(ctx, spec)
+	if err != nil {
+		return Exit{Code: -1}, err
+	}
+	return proc.Wait()
+}
+
+// newExitError returns err as an *ExitError if it reports that the command run as
+// command failed with exit, and err unchanged otherwise.
+func newExitError(command string, exit Exit, err error, stderr []byte, duration time.Duration) error {
+	if err == nil || exit.Code == 0 || exit.Code == -1 && exit.Signal == nil {
+		return err
+	}
+	e := &ExitError{
+		Command:  command,
+		ExitCode: exit.Code,
+		Signal:   exit.Signal,
+		Stderr:   lastBytes(stderr, maxStderrTail),
+		Duration: duration,
+	}
+	errors.As(err, &e.Err)
+	return e
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxStderrTail is the number of trailing stderr bytes kept in an ExitError.
+const maxStderrTail = 4 << 10
+
+// ExitError is returned when a command runs but exits with a non-zero code or is
+// terminated by a signal. It wraps the *exec.ExitError of commands run by
+// OSExecutor, so both can be retrieved with errors.As.
+//
+// Error returns a single line suitable for logs, ending with the last line of
+// stderr; format it with %+v to include the duration and the whole stderr tail.
+//
+// Example:
+//
+//	_, err := cmd.Exec("git", "push")
+//	var exitErr *cmd.ExitError
+//	if errors.As(err, &exitErr) && exitErr.ExitCode == 128 {
+//		log.Printf("%+v", exitErr)
+//	}
+type ExitError struct {
+	// Command is the command line, quoted for display.
+	Command string
+	// ExitCode is the exit code, or -1 if the command was terminated by a signal.
+	ExitCode int
+	// Signal is the signal that terminated the command, nil if it exited.
+	Signal os.Signal
+	// Stderr holds the last few KiB of the standard error output, or of the
+	// combined output for functions combining stdout and stderr.
+	Stderr string
+	// Duration is how long the command ran.
+	Duration time.Duration
+	// Err is the underlying error, nil for commands run by an Executor other
+	// than OSExecutor.
+	Err *exec.ExitError
+}
+
+// Error returns the command line, the exit status and the last stderr line.
+func (e *ExitError) Error() string {
+	msg := e.status()
+	if line := lastLine(e.Stderr); line != "" {
+		msg += ": " + line
+	}
+	return msg
+}
+
+// Unwrap returns the underlying *exec.ExitError.
+func (e *ExitError) Unwrap() error {
+	if e.Err == nil {
+		return nil
+	}
+	return e.Err
+}
+
+// Format implements fmt.Formatter. The %+v verb prints the command line and exit
+// status followed by the duration and the indented stderr tail on separate lines.
+func (e *ExitError) Format(f fmt.State, verb rune) {
+	switch {
+	case verb == 'v' && f.Flag('+'):
+		var b strings.Builder
+		b.WriteString(e.status())
+		b.WriteString("\n  duration: ")
+		b.WriteString(e.Duration.Round(time.Millisecond).String())
+		if stderr := strings.TrimRight(e.Stderr, "\n"); stderr != "" {
+			b.WriteString("\n  stderr:")
+			for _, line := range strings.Split(stderr, "\n") {
+				b.WriteString("\n    ")
+				b.WriteString(line)
+			}
+		}
+		_, _ = f.Write([]byte(b.String()))
+	case verb == 'q':
+		_, _ = f.Write([]byte(strconv.Quote(e.Error())))
+	default:
+		_, _ = f.Write([]byte(e.Error()))
+	}
+}
+
+func (e *ExitError) status() string {
+	if e.Signal != nil {
+		return e.Command + ": signal: " + e.Signal.String()
+	}
+	return e.Command + ": exit status " + strconv.Itoa(e.ExitCode)
+}
+
+// lastBytes returns the last n bytes of b, starting at a line boundary if possible.
+func lastBytes(b []byte, n int) string {
+	if len(b) <= n {
+		return string(b)
+	}
+	b = b[len(b)-n:]
+	if i := strings.IndexByte(string(b), '\n'); i >= 0 && i < len(b)-1 {
+		b = b[i+1:]
+	}
+	return string(b)
+}
+
+func lastLine(s string) string {
+	s = strings.TrimRight(s, "\r\n")
+	return strings.TrimSpace(s[strings.LastIndexByte(s, '\n')+1:])
+}
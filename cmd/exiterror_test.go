@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestExitError_Exec 测试Exec返回结构化的退出错误
+func TestExitError_Exec(t *testing.T) {
+	_, err := Exec("bash", "-c", "echo progress; echo 'fatal: bad thing' >&2; exit 3")
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("Expected an *ExitError, got %T %v", err, err)
+	}
+	if exitErr.ExitCode != 3 || exitErr.Signal != nil || exitErr.Duration <= 0 {
+		t.Errorf("Unexpected exit error %+v", exitErr)
+	}
+	if exitErr.Command != `bash -c 'echo progress; echo '\''fatal: bad thing'\'' >&2; exit 3'` {
+		t.Errorf("Unexpected command line %s", exitErr.Command)
+	}
+	if !strings.Contains(exitErr.Stderr, "fatal: bad thing") {
+		t.Errorf("Expected the output tail, got %q", exitErr.Stderr)
+	}
+	if !strings.HasSuffix(err.Error(), ": exit status 3: fatal: bad thing") {
+		t.Errorf("Unexpected message %q", err.Error())
+	}
+
+	var osErr *exec.ExitError
+	if !errors.As(err, &osErr) || osErr.ExitCode() != 3 {
+		t.Error("Expected the *exec.ExitError to be reachable with errors.As")
+	}
+
+	if _, err := Exec("nonexistentcommand12345"); errors.As(err, &exitErr) {
+		t.Error("Start failures should not be reported as *ExitError")
+	}
+}
+
+// TestExitError_Command 测试Command、ExecSeparate与Pipeline的退出错误
+func TestExitError_Command(t *testing.T) {
+	_, err := NewCommand("sh", "-c", "echo out; echo err >&2; exit 1").Run()
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Stderr != "err\n" {
+		t.Errorf("Expected only stderr in the exit error, got %v", err)
+	}
+
+	_, _, err = ExecSeparate("sh", "-c", "echo separate >&2; exit 2")
+	if !errors.As(err, &exitErr) || exitErr.ExitCode != 2 || exitErr.Stderr != "separate\n" {
+		t.Errorf("Expected an exit error from ExecSeparate, got %v", err)
+	}
+
+	_, err = NewCommand("sh", "-c", "echo streamed >&2; exit 4").StreamLines(func(Line) {})
+	if !errors.As(err, &exitErr) || exitErr.ExitCode != 4 || exitErr.Stderr != "streamed\n" {
+		t.Errorf("Expected an exit error from StreamLines, got %v", err)
+	}
+
+	_, err = NewPipeline().Pipe("sh", "-c", "echo broken >&2; exit 5").Pipe("cat").Pipefail().Run()
+	if !errors.As(err, &exitErr) || exitErr.ExitCode != 5 || exitErr.Stderr != "broken\n" {
+		t.Errorf("Expected an exit error from the failing stage, got %v", err)
+	}
+}
+
+// TestExitError_Format 测试日志格式化输出
+func TestExitError_Format(t *testing.T) {
+	err := &ExitError{
+		Command:  "git push",
+		ExitCode: 128,
+		Stderr:   "remote: denied\nfatal: could not read Username\n",
+		Duration: 1234 * time.Millisecond,
+	}
+	if got := fmt.Sprintf("%v", err); got != "git push: exit status 128: fatal: could not read Username" {
+		t.Errorf("%%v = %q", got)
+	}
+	want := "git push: exit status 128\n  duration: 1.234s\n  stderr:\n    remote: denied\n    fatal: could not read Username"
+	if got := fmt.Sprintf("%+v", err); got != want {
+		t.Errorf("%%+v = %q, want %q", got, want)
+	}
+	if got := fmt.Sprintf("%q", &ExitError{Command: "false", ExitCode: 1}); got != `"false: exit status 1"` {
+		t.Errorf("%%q = %s", got)
+	}
+}
+
+// TestLastBytes 测试stderr尾部截取
+func TestLastBytes(t *testing.T) {
+	if got := lastBytes([]byte("short"), 10); got != "short" {
+		t.Errorf("lastBytes() = %q", got)
+	}
+	if got := lastBytes([]byte("first line\nsecond\nthird"), 10); got != "third" {
+		t.Errorf("Expected the tail to start at a line boundary, got %q", got)
+	}
+	if got := lastBytes([]byte("abcdefghij"), 4); got != "ghij" {
+		t.Errorf("lastBytes() = %q", got)
+	}
+}
@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+)
+
+// ErrOutputLimit is reported for commands stopped because their output exceeded
+// the limit set with MaxOutput and OverflowFail.
+var ErrOutputLimit = errors.New("output limit exceeded")
+
+// Overflow tells what happens to output beyond the limit set with MaxOutput.
+type Overflow int
+
+const (
+	// OverflowTruncate keeps the output up to the limit and discards the rest,
+	// letting the command run to completion.
+	OverflowTruncate Overflow = iota
+	// OverflowFail stops the command as soon as the limit is exceeded and reports
+	// an error wrapping ErrOutputLimit.
+	OverflowFail
+)
+
+// MaxOutput limits the stdout and stderr kept by Run and Capture to n bytes
+// each, n bytes in total with CombinedOutput. Result.Truncated reports whether
+// output was cut. Zero means no limit.
+func (c *Command) MaxOutput(n int64, overflow Overflow) *Command {
+	c.maxOutput = n
+	c.overflow = overflow
+	return c
+}
+
+// SpillOver makes Capture keep at most threshold bytes of each stream in
+// memory; larger output is moved to a temporary file, removed by
+// Captured.Close. Run is not affected. Zero keeps everything in memory.
+func (c *Command) SpillOver(threshold int64) *Command {
+	c.spill = threshold
+	return c
+}
+
+// Captured is the outcome of Command.Capture. The embedded Result describes the
+// run, but its Stdout and Stderr are empty: the output is in Output and
+// ErrOutput.
+type Captured struct {
+	*Result
+	// Output is the standard output, or the combined output with CombinedOutput.
+	Output *Output
+	// ErrOutput is the standard error output, empty with CombinedOutput.
+	ErrOutput *Output
+}
+
+// Close removes the temporary files holding spilled output.
+func (c *Captured) Close() error {
+	return errors.Join(c.Output.Remove(), c.ErrOutput.Remove())
+}
+
+// Capture runs the command like Run and returns its output as bytes, which may
+// be binary. With SpillOver, large output is kept in temporary files, so the
+// Captured must be closed once read.
+//
+// Example:
+//
+//	captured, err := cmd.NewCommand("pg_dump", "app").
+//		MaxOutput(1<<30, cmd.OverflowFail).
+//		SpillOver(16 << 20).
+//		Capture()
+//	if err != nil {
+//		return err
+//	}
+//	defer captured.Close()
+//	dump, err := captured.Output.Open()
+func (c *Command) Capture() (*Captured, error) {
+	return c.runWith(c.baseContext(), c.timeout, true)
+}
+
+// Output is the captured output of one stream of a command. It is kept in
+// memory, or in a temporary file once larger than the SpillOver threshold.
+type Output struct {
+	limit      int64
+	overflow   Overflow
+	threshold  int64
+	onOverflow func()
+
+	buf       bytes.Buffer
+	file      *os.File
+	path      string
+	size      int64
+	truncated bool
+}
+
+// Bytes returns the output, read from its temporary file if it was spilled.
+func (o *Output) Bytes() ([]byte, error) {
+	if o.path == "" {
+		return o.buf.Bytes(), nil
+	}
+	return os.ReadFile(o.path)
+}
+
+// String returns the output as a string, empty if it cannot be read back from
+// its temporary file.
+func (o *Output) String() string {
+	b, _ := o.Bytes()
+	return string(b)
+}
+
+// Open returns a reader over the output, to be closed after use.
+func (o *Output) Open() (io.ReadCloser, error) {
+	if o.path == "" {
+		return io.NopCloser(bytes.NewReader(o.buf.Bytes())), nil
+	}
+	return os.Open(o.path)
+}
+
+// Len returns the number of bytes kept.
+func (o *Output) Len() int64 {
+	return o.size
+}
+
+// Truncated reports whether output beyond the MaxOutput limit was discarded.
+func (o *Output) Truncated() bool {
+	return o.truncated
+}
+
+// Path returns the temporary file holding the output, empty if it is in memory.
+func (o *Output) Path() string {
+	return o.path
+}
+
+// Remove removes the temporary file holding the output, if any.
+func (o *Output) Remove() error {
+	if o.path == "" {
+		return nil
+	}
+	err := os.Remove(o.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// write keeps p within the limit and always reports it written in full, so the
+// command is not disturbed by discarded output.
+func (o *Output) write(p []byte) (int, error) {
+	n := len(p)
+	if o.limit > 0 && o.size+int64(len(p)) > o.limit {
+		p = p[:o.limit-o.size]
+		if !o.truncated {
+			o.truncated = true
+			if o.overflow == OverflowFail && o.onOverflow != nil {
+				o.onOverflow()
+			}
+		}
+	}
+	if len(p) == 0 {
+		return n, nil
+	}
+	if o.file == nil && o.threshold > 0 && o.size+int64(len(p)) > o.threshold {
+		if err := o.spill(); err != nil {
+			return 0, err
+		}
+	}
+	if o.file != nil {
+		if _, err := o.file.Write(p); err != nil {
+			return 0, err
+		}
+	} else {
+		o.buf.Write(p)
+	}
+	o.size += int64(len(p))
+	return n, nil
+}
+
+// spill moves the output to a temporary file.
+func (o *Output) spill() error {
+	f, err := os.CreateTemp("", "cmd-output-*")
+	if err != nil {
+		return err
+	}
+	o.file, o.path = f, f.Name()
+	if _, err := f.Write(o.buf.Bytes()); err != nil {
+		return err
+	}
+	o.buf = bytes.Buffer{}
+	return nil
+}
+
+// finish closes the temporary file once the command is done.
+func (o *Output) finish() error {
+	if o.file == nil {
+		return nil
+	}
+	err := o.file.Close()
+	o.file = nil
+	return err
+}
+
+// tail returns at most the last n bytes of the output.
+func (o *Output) tail(n int) []byte {
+	if o.path == "" {
+		b := o.buf.Bytes()
+		return b[max(len(b)-n, 0):]
+	}
+	f, err := os.Open(o.path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	b := make([]byte, min(o.size, int64(n)))
+	if _, err := f.ReadAt(b, o.size-int64(len(b))); err != nil {
+		return nil
+	}
+	return b
+}
+
+// outputWriter is the io.Writer given to the Executor for an Output.
+type outputWriter struct {
+	o *Output
+}
+
+func (w outputWriter) Write(p []byte) (int, error) {
+	return w.o.write(p)
+}
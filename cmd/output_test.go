@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go4x/goal/retry"
+)
+
+// TestCommand_MaxOutput 测试输出超出上限时截断
+func TestCommand_MaxOutput(t *testing.T) {
+	res, err := NewCommand("sh", "-c", "yes out | head -c 100000; yes err | head -c 100000 >&2").
+		MaxOutput(1000, OverflowTruncate).
+		Run()
+	if err != nil || res.ExitCode != 0 {
+		t.Fatalf("Truncation should let the command succeed, got %v", err)
+	}
+	if len(res.Stdout) != 1000 || len(res.Stderr) != 1000 || !res.Truncated {
+		t.Errorf("Expected 1000 bytes of each stream, got %d and %d", len(res.Stdout), len(res.Stderr))
+	}
+	if !strings.HasPrefix(res.Stdout, "out\nout\n") || !strings.HasPrefix(res.Stderr, "err\n") {
+		t.Errorf("Expected the beginning of the output, got %q", res.Stdout[:8])
+	}
+
+	res, _ = NewCommand("echo", "small").MaxOutput(1000, OverflowTruncate).Run()
+	if res.Truncated || res.Stdout != "small\n" {
+		t.Errorf("Output within the limit should be kept whole, got %+v", res)
+	}
+
+	res, _ = NewCommand("sh", "-c", "yes | head -c 3000").CombinedOutput().MaxOutput(1500, OverflowTruncate).Run()
+	if len(res.Stdout) != 1500 || !res.Truncated {
+		t.Errorf("Expected combined output to be limited, got %d bytes", len(res.Stdout))
+	}
+}
+
+// TestCommand_MaxOutputFail 测试输出超出上限时终止命令
+func TestCommand_MaxOutputFail(t *testing.T) {
+	start := time.Now()
+	res, err := NewCommand("yes").
+		MaxOutput(4096, OverflowFail).
+		Retry(3, retry.ConstantInterval(0)).
+		Run()
+	if !errors.Is(err, ErrOutputLimit) || err.Error() != "yes: output limit exceeded (4096 bytes)" {
+		t.Fatalf("Expected ErrOutputLimit, got %v", err)
+	}
+	if time.Since(start) > 3*time.Second {
+		t.Errorf("Expected the command to be stopped, took %s", time.Since(start))
+	}
+	if len(res.Stdout) != 4096 || !res.Truncated || res.Attempts != 1 || res.Termination != Terminated {
+		t.Errorf("Unexpected result %d bytes, %+v", len(res.Stdout), res)
+	}
+}
+
+// TestCommand_Capture 测试二进制输出与溢出到临时文件
+func TestCommand_Capture(t *testing.T) {
+	captured, err := NewCommand("printf", `\000\377\001`).Capture()
+	if err != nil {
+		t.Fatalf("Capture failed: %v", err)
+	}
+	if b, _ := captured.Output.Bytes(); !bytes.Equal(b, []byte{0, 0xff, 1}) || captured.Stdout != "" {
+		t.Errorf("Expected binary output, got %v", b)
+	}
+	if captured.ErrOutput.Len() != 0 || captured.Output.Path() != "" {
+		t.Errorf("Expected in-memory output, got %+v", captured)
+	}
+
+	captured, err = NewCommand("sh", "-c", "yes abc | head -c 300000").SpillOver(1024).Capture()
+	if err != nil {
+		t.Fatalf("Capture failed: %v", err)
+	}
+	path := captured.Output.Path()
+	if path == "" || captured.Output.Len() != 300000 || captured.Output.buf.Len() != 0 {
+		t.Fatalf("Expected the output to be spilled, got %q with %d bytes", path, captured.Output.Len())
+	}
+	r, err := captured.Output.Open()
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	data, _ := io.ReadAll(r)
+	_ = r.Close()
+	if len(data) != 300000 || !strings.HasPrefix(string(data), "abc\nabc\n") || captured.Output.String() != string(data) {
+		t.Errorf("Unexpected spilled output of %d bytes", len(data))
+	}
+	if err := captured.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected the temporary file to be removed, got %v", err)
+	}
+
+	captured, err = NewCommand("sh", "-c", "yes err | head -c 10000 >&2; echo last >&2; exit 2").
+		SpillOver(100).
+		Capture()
+	defer captured.Close()
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || len(exitErr.Stderr) > maxStderrTail || !strings.HasSuffix(exitErr.Stderr, "err\nlast\n") {
+		t.Errorf("Expected the stderr tail of a spilled output, got %v", err)
+	}
+	if captured.ErrOutput.Path() == "" || captured.ExitCode != 2 {
+		t.Errorf("Expected spilled stderr, got %+v", captured.Result)
+	}
+}
+
+// TestExecBytes 测试以字节形式返回输出
+func TestExecBytes(t *testing.T) {
+	output, err := ExecBytes("printf", `a\000b`)
+	if err != nil || !bytes.Equal(output, []byte("a\x00b")) {
+		t.Errorf("Unexpected output %q %v", output, err)
+	}
+	output, err = ExecBytes("sh", "-c", "echo oops >&2; exit 1")
+	if err == nil || string(output) != "oops\n" {
+		t.Errorf("Expected the output with the error, got %q %v", output, err)
+	}
+}
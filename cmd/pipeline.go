@@ -0,0 +1,275 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Pipeline runs commands connected like a shell pipeline `a | b | c`: the stdout
+// of each stage is the stdin of the next, and the stdout of the last stage is the
+// output of the pipeline.
+//
+// The working directory, environment, grace period and CombinedOutput of each
+// stage Command are honoured; CombinedOutput sends the stderr of the stage down
+// the pipe like `2>&1 |`. The stdin, context, timeout and retries of stages are
+// ignored in favour of those of the pipeline.
+//
+// Example:
+//
+//	res, err := cmd.NewPipeline().
+//		Pipe("journalctl", "-u", "app", "--no-pager").
+//		Pipe("grep", "ERROR").
+//		Pipe("tail", "-n", "20").
+//		Pipefail().
+//		Timeout(30 * time.Second).
+//		Run()
+//	for _, stage := range res.Stages {
+//		fmt.Println(stage.Command, stage.ExitCode, stage.Stderr)
+//	}
+type Pipeline struct {
+	stages    []*Command
+	tees      map[int]io.Writer
+	stdin     io.Reader
+	stdinData *string
+	ctx       context.Context
+	timeout   time.Duration
+	pipefail  bool
+}
+
+// PipelineResult is the outcome of running a Pipeline.
+type PipelineResult struct {
+	// Command is the pipeline command line, stages joined with " | ".
+	Command string
+	// Stdout is the standard output of the last stage.
+	Stdout string
+	// Stages holds the result of each stage. Their Stdout is empty, as it was
+	// piped into the next stage, except for the last stage.
+	Stages []*Result
+	// ExitCode is the exit code of the last stage or, with Pipefail, of the last
+	// stage that failed.
+	ExitCode int
+	// Duration is the wall time of the whole pipeline.
+	Duration time.Duration
+}
+
+// Success reports whether the pipeline exit code is 0.
+func (r *PipelineResult) Success() bool {
+	return r.ExitCode == 0
+}
+
+// NewPipeline creates a pipeline of the given stages. More stages can be added
+// with Pipe and Then.
+func NewPipeline(stages ...*Command) *Pipeline {
+	return &Pipeline{stages: stages}
+}
+
+// Pipe appends a stage running name with the given arguments.
+func (p *Pipeline) Pipe(name string, args ...string) *Pipeline {
+	return p.Then(NewCommand(name, args...))
+}
+
+// Then appends a stage, for stages needing their own directory or environment.
+func (p *Pipeline) Then(stage *Command) *Pipeline {
+	p.stages = append(p.stages, stage)
+	return p
+}
+
+// Tee copies the stdout of the stage at index stage, starting from 0, to w as it
+// flows to the next stage. w is written from a separate goroutine.
+func (p *Pipeline) Tee(stage int, w io.Writer) *Pipeline {
+	if p.tees == nil {
+		p.tees = make(map[int]io.Writer)
+	}
+	p.tees[stage] = w
+	return p
+}
+
+// Stdin sets the standard input of the first stage.
+func (p *Pipeline) Stdin(r io.Reader) *Pipeline {
+	p.stdin = r
+	p.stdinData = nil
+	return p
+}
+
+// StdinString sets the standard input of the first stage to s.
+func (p *Pipeline) StdinString(s string) *Pipeline {
+	p.stdin = nil
+	p.stdinData = &s
+	return p
+}
+
+// Context sets a context that stops every stage when done.
+func (p *Pipeline) Context(ctx context.Context) *Pipeline {
+	p.ctx = ctx
+	return p
+}
+
+// Timeout limits the duration of the whole pipeline. Zero means no timeout.
+func (p *Pipeline) Timeout(timeout time.Duration) *Pipeline {
+	p.timeout = timeout
+	return p
+}
+
+// Pipefail makes the pipeline fail when any stage fails, like `set -o pipefail`
+// in bash. By default only the last stage decides the exit code and error.
+func (p *Pipeline) Pipefail() *Pipeline {
+	p.pipefail = true
+	return p
+}
+
+// String returns the pipeline command line, quoted for display.
+func (p *Pipeline) String() string {
+	stages := make([]string, len(p.stages))
+	for i, stage := range p.stages {
+		stages[i] = stage.String()
+	}
+	return strings.Join(stages, " | ")
+}
+
+// Run runs all stages concurrently and waits for them to finish. The result is
+// returned even if the pipeline fails. The error names the failing stage: the last
+// one, or with Pipefail the last one that failed, and wraps its *ExitError.
+func (p *Pipeline) Run() (*PipelineResult, error) {
+	res := &PipelineResult{Command: p.String(), Stages: make([]*Result, len(p.stages))}
+	if len(p.stages) == 0 {
+		return res, fmt.Errorf("pipeline has no stages")
+	}
+
+	ctx := p.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+	// Cancelled to stop the started stages when a later one cannot be started.
+	runCtx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	var stdin io.Reader = p.stdin
+	if p.stdinData != nil {
+		stdin = strings.NewReader(*p.stdinData)
+	}
+
+	var (
+		wg       sync.WaitGroup
+		stdout   bytes.Buffer
+		errs     = make([]error, len(p.stages))
+		failed   = -1
+		previous *os.File // read end of the pipe from the previous stage
+	)
+	start := time.Now()
+	for i, stage := range p.stages {
+		// The ends of the pipes of a stage are closed in the parent once it is
+		// done, so that the next stage sees EOF and the previous one SIGPIPE.
+		var next, pipeWriter *os.File
+		var out io.Writer = &stdout
+		if i < len(p.stages)-1 {
+			var err error
+			if next, pipeWriter, err = os.Pipe(); err != nil {
+				if previous != nil {
+					_ = previous.Close()
+				}
+				errs[i], failed = err, i
+				break
+			}
+			out = pipeWriter
+		}
+		if tee := p.tees[i]; tee != nil {
+			out = io.MultiWriter(out, tee)
+		}
+
+		spec := stage.spec()
+		spec.Stdin = stdin
+		spec.Stdout = out
+		stderr := &bytes.Buffer{}
+		spec.Stderr = stderr
+		if stage.combined {
+			spec.Stderr = out
+		}
+		executor := stage.executor
+		if executor == nil {
+			executor = DefaultExecutor
+		}
+
+		stageStart := time.Now()
+		proc, err := executor.Start(runCtx, spec)
+		if err != nil {
+			errs[i], failed = err, i
+			for _, f := range []*os.File{previous, next, pipeWriter} {
+				if f != nil {
+					_ = f.Close()
+				}
+			}
+			break
+		}
+
+		wg.Add(1)
+		go func(stdin *os.File) {
+			defer wg.Done()
+			exit, err := proc.Wait()
+			for _, f := range []*os.File{stdin, pipeWriter} {
+				if f != nil {
+					_ = f.Close()
+				}
+			}
+			res.Stages[i] = &Result{
+				Command:     stage.String(),
+				Stderr:      stderr.String(),
+				ExitCode:    exit.Code,
+				Duration:    time.Since(stageStart),
+				Termination: exit.Termination,
+				Attempts:    1,
+			}
+			errs[i] = newExitError(res.Stages[i].Command, exit, err, stderr.Bytes(), res.Stages[i].Duration)
+		}(previous)
+		stdin, previous = next, next
+	}
+	if failed >= 0 {
+		stop()
+	}
+	wg.Wait()
+	res.Duration = time.Since(start)
+
+	for i, stage := range res.Stages {
+		if stage == nil {
+			// Not started because this or an earlier stage could not be started.
+			res.Stages[i] = &Result{Command: p.stages[i].String(), ExitCode: -1}
+		}
+	}
+	last := len(p.stages) - 1
+	res.Stages[last].Stdout = stdout.String()
+	res.Stdout = stdout.String()
+	if failed >= 0 {
+		res.ExitCode = -1
+		return res, p.stageError(ctx, failed, errs)
+	}
+
+	failed = last
+	if p.pipefail {
+		for i := last; i >= 0; i-- {
+			if errs[i] != nil {
+				failed = i
+				break
+			}
+		}
+	}
+	res.ExitCode = res.Stages[failed].ExitCode
+	return res, p.stageError(ctx, failed, errs)
+}
+
+// stageError wraps the error of stage i with its position.
+func (p *Pipeline) stageError(ctx context.Context, i int, errs []error) error {
+	if errs[i] == nil {
+		return nil
+	}
+	return contextError(ctx, fmt.Errorf("stage %d: %w", i+1, errs[i]))
+}
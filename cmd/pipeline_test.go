@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestPipeline_Run 测试多阶段管道
+func TestPipeline_Run(t *testing.T) {
+	var tee bytes.Buffer
+	res, err := NewPipeline().
+		Pipe("printf", `b\na\nc\na\n`).
+		Pipe("sort").
+		Pipe("uniq", "-c").
+		Pipe("awk", `{print $2 "=" $1}`).
+		Tee(1, &tee).
+		Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Stdout != "a=2\nb=1\nc=1\n" || !res.Success() {
+		t.Errorf("Unexpected output %q", res.Stdout)
+	}
+	if tee.String() != "a\na\nb\nc\n" {
+		t.Errorf("Expected the sorted stage output in the tee, got %q", tee.String())
+	}
+	if len(res.Stages) != 4 || res.Stages[0].Stdout != "" || res.Stages[3].Stdout != res.Stdout {
+		t.Errorf("Unexpected stages %+v", res.Stages)
+	}
+	if !strings.HasPrefix(res.Command, `printf 'b\na\nc\na\n' | sort | uniq -c | awk`) {
+		t.Errorf("Unexpected command line %s", res.Command)
+	}
+}
+
+// TestPipeline_Pipefail 测试pipefail语义与分阶段stderr
+func TestPipeline_Pipefail(t *testing.T) {
+	build := func() *Pipeline {
+		return NewPipeline().
+			Pipe("sh", "-c", "echo data; echo first failed >&2; exit 3").
+			Pipe("sh", "-c", "cat; echo second warned >&2").
+			Pipe("cat")
+	}
+
+	res, err := build().Run()
+	if err != nil || res.ExitCode != 0 {
+		t.Errorf("Without pipefail only the last stage counts, got %d %v", res.ExitCode, err)
+	}
+	if res.Stages[0].ExitCode != 3 || res.Stages[0].Stderr != "first failed\n" || res.Stages[1].Stderr != "second warned\n" {
+		t.Errorf("Unexpected stage results %+v %+v", res.Stages[0], res.Stages[1])
+	}
+
+	res, err = build().Pipefail().Run()
+	if err == nil || res.ExitCode != 3 || res.Stdout != "data\n" {
+		t.Errorf("Expected pipefail to report stage 1, got %d %q %v", res.ExitCode, res.Stdout, err)
+	}
+	if err != nil && !strings.HasPrefix(err.Error(), "stage 1: sh -c ") {
+		t.Errorf("Expected the error to name the stage, got %v", err)
+	}
+}
+
+// TestPipeline_StdinAndStages 测试标准输入、合并输出与独立阶段配置
+func TestPipeline_StdinAndStages(t *testing.T) {
+	dir := t.TempDir()
+	res, err := NewPipeline(
+		NewCommand("sh", "-c", "cat; echo to-stderr >&2").CombinedOutput(),
+		NewCommand("sh", "-c", `tr a-z A-Z; echo "$STAGE"; pwd`).Env("STAGE", "second").Dir(dir),
+	).StdinString("hello\n").Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(res.Stdout), "\n")
+	if len(lines) != 4 || lines[0] != "HELLO" || lines[1] != "TO-STDERR" || lines[2] != "second" ||
+		filepath.Base(lines[3]) != filepath.Base(dir) {
+		t.Errorf("Unexpected output %q", res.Stdout)
+	}
+}
+
+// TestPipeline_Errors 测试启动失败与超时
+func TestPipeline_Errors(t *testing.T) {
+	res, err := NewPipeline().Pipe("sleep", "5").Pipe("nonexistentcommand12345").Pipe("cat").Run()
+	if err == nil || !strings.HasPrefix(err.Error(), "stage 2: exec: \"nonexistentcommand12345\"") || res.ExitCode != -1 {
+		t.Errorf("Expected a start error for stage 2, got %v", err)
+	}
+	if len(res.Stages) != 3 || res.Stages[0].Termination != Terminated || res.Stages[2].ExitCode != -1 {
+		t.Errorf("Expected started stages to be stopped, got %+v", res.Stages)
+	}
+
+	start := time.Now()
+	_, err = NewPipeline().Pipe("sleep", "5").Pipe("cat").Timeout(100 * time.Millisecond).Pipefail().Run()
+	if !errors.Is(err, context.DeadlineExceeded) || time.Since(start) > 3*time.Second {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+
+	if _, err := NewPipeline().Run(); err == nil {
+		t.Error("Expected an error for an empty pipeline")
+	}
+}
+
+// TestPipeline_EarlyExit 测试下游提前退出时上游收到SIGPIPE
+func TestPipeline_EarlyExit(t *testing.T) {
+	res, err := NewPipeline().Pipe("yes").Pipe("head", "-n", "2").Timeout(5 * time.Second).Run()
+	if err != nil || res.Stdout != "y\ny\n" {
+		t.Errorf("Expected head to end the pipeline, got %q %v", res.Stdout, err)
+	}
+	if res.Stages[0].Termination != Exited {
+		t.Errorf("Expected yes to exit on SIGPIPE, got %s", res.Stages[0].Termination)
+	}
+}
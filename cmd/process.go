@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// DefaultGracePeriod is how long a command stopped by its context may take to
+// exit after SIGTERM before its process group is killed.
+const DefaultGracePeriod = 5 * time.Second
+
+// Termination tells how a command ended.
+type Termination int
+
+const (
+	// Exited means the command exited on its own.
+	Exited Termination = iota
+	// Terminated means the command exited after SIGTERM was sent to its
+	// process group because its context was done.
+	Terminated
+	// Killed means the command was killed with SIGKILL, because it outlived the
+	// grace period or the grace period was zero. On systems without process
+	// groups, commands stopped by their context are always killed.
+	Killed
+)
+
+// String returns "exited", "terminated" or "killed".
+func (t Termination) String() string {
+	switch t {
+	case Exited:
+		return "exited"
+	case Terminated:
+		return "terminated"
+	case Killed:
+		return "killed"
+	default:
+		return fmt.Sprintf("Termination(%d)", int(t))
+	}
+}
+
+// processGroup stops a command and the processes it spawned when its context is
+// done. On Unix the command runs in its own process group, which is sent SIGTERM,
+// then SIGKILL once the grace period has elapsed.
+type processGroup struct {
+	grace time.Duration
+
+	mu     sync.Mutex
+	state  Termination
+	exited bool
+}
+
+// commandContext is like exec.CommandContext, but stops the whole process group
+// of the command gracefully when ctx is done.
+func commandContext(ctx context.Context, grace time.Duration, name string, args ...string) (*exec.Cmd, *processGroup) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	g := &processGroup{grace: grace}
+	g.attach(cmd)
+	return cmd, g
+}
+
+// finish records that the command has been waited for and returns how it ended.
+func (g *processGroup) finish() Termination {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.exited = true
+	return g.state
+}
+
+func (g *processGroup) setTermination(t Termination) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.state = t
+}
+
+// contextError annotates err with the error of ctx when the command was stopped
+// by it, so callers can test for context.DeadlineExceeded or context.Canceled.
+func contextError(ctx context.Context, err error) error {
+	if err != nil && ctx.Err() != nil {
+		return fmt.Errorf("%w: %w", ctx.Err(), err)
+	}
+	return err
+}
@@ -0,0 +1,17 @@
+//go:build !unix
+
+package cmd
+
+import "os/exec"
+
+// attach kills cmd when its context is done. Process groups are not supported,
+// so processes spawned by the command are not stopped.
+func (g *processGroup) attach(cmd *exec.Cmd) {
+	cmd.Cancel = func() error {
+		if err := cmd.Process.Kill(); err != nil {
+			return err
+		}
+		g.setTermination(Killed)
+		return nil
+	}
+}
@@ -0,0 +1,51 @@
+//go:build unix
+
+package cmd
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// attach makes cmd the leader of a new process group, so that signals reach the
+// processes it spawns. As a consequence, the command no longer receives signals
+// sent to the terminal's foreground group, such as SIGINT on Ctrl-C.
+func (g *processGroup) attach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		pgid := -cmd.Process.Pid
+		if g.grace <= 0 {
+			if err := signalGroup(pgid, syscall.SIGKILL); err != nil {
+				return err
+			}
+			g.setTermination(Killed)
+			return nil
+		}
+		if err := signalGroup(pgid, syscall.SIGTERM); err != nil {
+			return err
+		}
+		g.setTermination(Terminated)
+		// Kill whatever is left of the group after the grace period, including
+		// children that outlived the leader.
+		time.AfterFunc(g.grace, func() {
+			g.mu.Lock()
+			if !g.exited {
+				g.state = Killed
+			}
+			g.mu.Unlock()
+			_ = signalGroup(pgid, syscall.SIGKILL)
+		})
+		return nil
+	}
+}
+
+func signalGroup(pgid int, sig syscall.Signal) error {
+	err := syscall.Kill(pgid, sig)
+	if errors.Is(err, syscall.ESRCH) {
+		return os.ErrProcessDone
+	}
+	return err
+}
@@ -0,0 +1,85 @@
+//go:build unix
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestCommand_KillsProcessGroup 测试超时后终止整个进程组，不留下孤儿进程
+func TestCommand_KillsProcessGroup(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "marker")
+	res, err := NewCommand("sh", "-c", `(sleep 0.5; touch "$0") & wait`, marker).
+		Timeout(100 * time.Millisecond).
+		Run()
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	if res.Termination != Terminated {
+		t.Errorf("Expected the command to be terminated, got %s", res.Termination)
+	}
+
+	time.Sleep(time.Second)
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("The background child should have been stopped with its group")
+	}
+}
+
+// TestCommand_GracePeriod 测试忽略SIGTERM的进程在宽限期后被强制杀死
+func TestCommand_GracePeriod(t *testing.T) {
+	start := time.Now()
+	res, err := NewCommand("sh", "-c", `trap "" TERM; sleep 5`).
+		Timeout(100 * time.Millisecond).
+		GracePeriod(300 * time.Millisecond).
+		Run()
+	if err == nil || res.Termination != Killed || res.ExitCode != -1 {
+		t.Errorf("Expected the command to be killed, got %+v %v", res, err)
+	}
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond || elapsed > 3*time.Second {
+		t.Errorf("Expected the kill after the grace period, took %s", elapsed)
+	}
+
+	res, _ = NewCommand("sleep", "5").Timeout(50 * time.Millisecond).GracePeriod(0).Run()
+	if res.Termination != Killed {
+		t.Errorf("A zero grace period should kill right away, got %s", res.Termination)
+	}
+
+	res, _ = NewCommand("sh", "-c", "exit 2").Timeout(time.Second).Run()
+	if res.Termination != Exited || res.ExitCode != 2 {
+		t.Errorf("Expected the command to exit on its own, got %+v", res)
+	}
+}
+
+// TestExecWithContext_ProcessGroup 测试ExecWithContext取消时终止子进程
+func TestExecWithContext_ProcessGroup(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "marker")
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err := ExecWithContext(ctx, "sh", "-c", `(sleep 0.5; touch "$0") & wait`, marker)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+	time.Sleep(time.Second)
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("The background child should have been stopped with its group")
+	}
+}
+
+// TestExitError_Signal 测试被信号终止的命令
+func TestExitError_Signal(t *testing.T) {
+	_, err := NewCommand("sleep", "5").Timeout(50 * time.Millisecond).GracePeriod(0).Run()
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Signal != syscall.SIGKILL || exitErr.ExitCode != -1 {
+		t.Fatalf("Expected an exit error for SIGKILL, got %v", err)
+	}
+	if exitErr.Error() != "sleep 5: signal: killed" {
+		t.Errorf("Unexpected message %q", exitErr.Error())
+	}
+}
@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go4x/goal/stringx"
+)
+
+// Split splits a command line into words following the quoting rules of a POSIX
+// shell, so that command lines from configuration files can be passed to the
+// Exec functions:
+//
+//   - blanks separate words; backslash-newline joins lines
+//   - a backslash outside quotes keeps the next character literally
+//   - single quotes keep everything up to the next single quote literally
+//   - in double quotes, a backslash only escapes $, `, ", \ and newline
+//
+// If mapping is not nil, $NAME and ${NAME} outside single quotes are replaced by
+// mapping(NAME); pass os.Getenv to expand environment variables. Unlike a shell,
+// expanded values are not split into several words. If mapping is nil, $ is an
+// ordinary character.
+//
+// Operators such as |, ;, && and >, globs and comments are not interpreted:
+// they are ordinary characters. Use a Pipeline to connect commands.
+//
+// Example:
+//
+//	words, err := cmd.Split(`grep -r "TODO: fix" '$HOME/src' --include=*.go`, nil)
+//	// words: grep, -r, TODO: fix, $HOME/src, --include=*.go
+func Split(s string, mapping func(string) string) ([]string, error) {
+	var (
+		words  []string
+		word   strings.Builder
+		inWord bool // a word was started, possibly by empty quotes
+	)
+	for i := 0; i < len(s); {
+		switch c := s[i]; c {
+		case ' ', '\t', '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+			i++
+		case '\\':
+			if i+1 == len(s) {
+				return nil, fmt.Errorf("trailing backslash at offset %d", i)
+			}
+			if s[i+1] != '\n' {
+				word.WriteByte(s[i+1])
+				inWord = true
+			}
+			i += 2
+		case '\'':
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated single quote at offset %d", i)
+			}
+			word.WriteString(s[i+1 : i+1+end])
+			inWord = true
+			i += end + 2
+		case '"':
+			n, err := splitDoubleQuoted(s[i:], &word, mapping)
+			if err != nil {
+				return nil, fmt.Errorf("%w at offset %d", err, i)
+			}
+			inWord = true
+			i += n
+		case '$':
+			if mapping == nil {
+				word.WriteByte(c)
+				inWord = true
+				i++
+				continue
+			}
+			n, value, err := expandVariable(s[i:], mapping)
+			if err != nil {
+				return nil, fmt.Errorf("%w at offset %d", err, i)
+			}
+			// Like a shell, an unquoted empty expansion does not make a word.
+			if value != "" {
+				word.WriteString(value)
+				inWord = true
+			}
+			i += n
+		default:
+			word.WriteByte(c)
+			inWord = true
+			i++
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// ParseCommand splits a command line with Split and returns the command name
+// and its arguments, ready for the Exec functions or NewCommand.
+//
+// Example:
+//
+//	shell, args, err := cmd.ParseCommand(cfg.HealthCheck, os.Getenv)
+//	if err != nil {
+//		return err
+//	}
+//	output, err := cmd.ExecWithTimeout(5*time.Second, shell, args...)
+func ParseCommand(line string, mapping func(string) string) (string, []string, error) {
+	words, err := Split(line, mapping)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(words) == 0 {
+		return "", nil, errors.New("empty command line")
+	}
+	return words[0], words[1:], nil
+}
+
+// Quote returns s quoted for a POSIX shell, so that it is read back as a single
+// word. Words made of safe characters only are returned unchanged.
+func Quote(s string) string {
+	return stringx.ShellQuote(s)
+}
+
+// Join quotes each argument with Quote and joins them with spaces. The result is
+// safe to display or paste into a shell, and Split turns it back into args.
+func Join(args []string) string {
+	words := make([]string, len(args))
+	for i, arg := range args {
+		words[i] = Quote(arg)
+		if i == 0 && words[i] == arg && strings.ContainsRune(arg, '=') {
+			// A leading NAME=value word would be taken as an assignment.
+			words[i] = "'" + arg + "'"
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// splitDoubleQuoted writes the content of the double-quoted string at the start
+// of s to word and returns the number of bytes consumed, closing quote included.
+func splitDoubleQuoted(s string, word *strings.Builder, mapping func(string) string) (int, error) {
+	for i := 1; i < len(s); {
+		switch c := s[i]; {
+		case c == '"':
+			return i + 1, nil
+		case c == '\\' && i+1 < len(s) && strings.IndexByte("$`\"\\\n", s[i+1]) >= 0:
+			if s[i+1] != '\n' {
+				word.WriteByte(s[i+1])
+			}
+			i += 2
+		case c == '$' && mapping != nil:
+			n, value, err := expandVariable(s[i:], mapping)
+			if err != nil {
+				return 0, err
+			}
+			word.WriteString(value)
+			i += n
+		default:
+			word.WriteByte(c)
+			i++
+		}
+	}
+	return 0, errors.New("unterminated double quote")
+}
+
+// expandVariable expands the $NAME or ${NAME} reference at the start of s and
+// returns the number of bytes consumed. A $ not followed by a name is literal.
+func expandVariable(s string, mapping func(string) string) (int, string, error) {
+	if strings.HasPrefix(s, "${") {
+		end := strings.IndexByte(s, '}')
+		if end < 0 {
+			return 0, "", errors.New("unterminated ${")
+		}
+		name := s[2:end]
+		if nameLength(name) != len(name) || name == "" {
+			return 0, "", fmt.Errorf("unsupported parameter expansion %q", s[:end+1])
+		}
+		return end + 1, mapping(name), nil
+	}
+	n := nameLength(s[1:])
+	if n == 0 {
+		return 1, "$", nil
+	}
+	return n + 1, mapping(s[1 : n+1]), nil
+}
+
+// nameLength returns the length of the variable name at the start of s.
+func nameLength(s string) int {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 0 && c >= '0' && c <= '9' {
+			continue
+		}
+		return i
+	}
+	return len(s)
+}
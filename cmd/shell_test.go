@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestSplit 测试按POSIX shell规则拆分命令行
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"", nil},
+		{"  \t\n ", nil},
+		{"ls -la /tmp", []string{"ls", "-la", "/tmp"}},
+		{`echo "hello world"`, []string{"echo", "hello world"}},
+		{`echo 'it''s'`, []string{"echo", "its"}},
+		{`echo 'a "b" \c'`, []string{"echo", `a "b" \c`}},
+		{`echo "a \"b\" \c \\ \$"`, []string{"echo", `a "b" \c \ $`}},
+		{`echo a\ b \'c\'`, []string{"echo", "a b", "'c'"}},
+		{`echo "" ''`, []string{"echo", "", ""}},
+		{"echo a\\\nb", []string{"echo", "ab"}},
+		{`pre"mid"'post'`, []string{"premidpost"}},
+		{`echo $HOME "${HOME}" | wc`, []string{"echo", "$HOME", "${HOME}", "|", "wc"}},
+		{"echo 日本 '語'", []string{"echo", "日本", "語"}},
+	}
+	for _, tt := range tests {
+		got, err := Split(tt.in, nil)
+		if err != nil {
+			t.Errorf("Split(%q) returned error: %v", tt.in, err)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Split(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// TestSplit_Expand 测试环境变量展开
+func TestSplit_Expand(t *testing.T) {
+	vars := map[string]string{"HOME": "/home/me", "MSG": "two words", "EMPTY": ""}
+	mapping := func(name string) string { return vars[name] }
+
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"cd $HOME/src", []string{"cd", "/home/me/src"}},
+		{"cd ${HOME}src", []string{"cd", "/home/mesrc"}},
+		{`echo "$MSG" $MSG`, []string{"echo", "two words", "two words"}},
+		{`echo '$HOME' \$HOME`, []string{"echo", "$HOME", "$HOME"}},
+		{`echo $EMPTY "$EMPTY" x`, []string{"echo", "", "x"}},
+		{"echo $ $1 a$", []string{"echo", "$", "$1", "a$"}},
+		{"echo $UNSET_VAR", []string{"echo"}},
+	}
+	for _, tt := range tests {
+		got, err := Split(tt.in, mapping)
+		if err != nil {
+			t.Errorf("Split(%q) returned error: %v", tt.in, err)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("Split(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// TestSplit_Errors 测试语法错误
+func TestSplit_Errors(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`echo 'abc`, "unterminated single quote at offset 5"},
+		{`echo "abc`, "unterminated double quote at offset 5"},
+		{`echo abc\`, "trailing backslash at offset 8"},
+		{`echo ${HOME`, "unterminated ${ at offset 5"},
+		{`echo "${HOME:-x}"`, `unsupported parameter expansion "${HOME:-x}" at offset 5`},
+	}
+	for _, tt := range tests {
+		_, err := Split(tt.in, func(string) string { return "" })
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("Split(%q) error = %v, want %q", tt.in, err, tt.want)
+		}
+	}
+}
+
+// TestParseCommand 测试解析命令名与参数
+func TestParseCommand(t *testing.T) {
+	shell, args, err := ParseCommand(`sh -c 'echo "$0"' $USER_NAME`, func(string) string { return "bob" })
+	if err != nil {
+		t.Fatalf("ParseCommand returned error: %v", err)
+	}
+	if shell != "sh" || !slices.Equal(args, []string{"-c", `echo "$0"`, "bob"}) {
+		t.Errorf("Unexpected command %q %q", shell, args)
+	}
+	output, err := Exec(shell, args...)
+	if err != nil || output != "bob\n" {
+		t.Errorf("Expected the parsed command to run, got %q %v", output, err)
+	}
+
+	if _, _, err := ParseCommand("  ", nil); err == nil {
+		t.Error("Expected an error for an empty command line")
+	}
+}
+
+// TestQuote 测试参数引用及与Split的往返
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"", "''"},
+		{"simple", "simple"},
+		{"--flag=a,b:c/d@e%f+g", "--flag=a,b:c/d@e%f+g"},
+		{"two words", "'two words'"},
+		{"it's", `'it'\''s'`},
+		{"$HOME", "'$HOME'"},
+		{"a\nb", "'a\nb'"},
+		{"~", "'~'"},
+		{"日本", "'日本'"},
+	}
+	for _, tt := range tests {
+		if got := Quote(tt.in); got != tt.want {
+			t.Errorf("Quote(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	args := []string{"FOO=bar", "a b", `"q"`, "it's", "", "$(rm -rf /)", "*", `\`, "tab\there"}
+	line := Join(args)
+	if !strings.HasPrefix(line, "'FOO=bar' ") {
+		t.Errorf("Expected a leading assignment to be quoted, got %s", line)
+	}
+	got, err := Split(line, func(string) string { return "EXPANDED" })
+	if err != nil || !slices.Equal(got, args) {
+		t.Errorf("Split(Join(args)) = %q %v, want %q", got, err, args)
+	}
+	output, err := Exec("sh", "-c", "printf '%s|' "+Join(args[1:]))
+	if err != nil || output != strings.Join(args[1:], "|")+"|" {
+		t.Errorf("Expected the shell to read back the args, got %q %v", output, err)
+	}
+}
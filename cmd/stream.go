@@ -0,0 +1,256 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"iter"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTailLines is the number of output lines kept in Result.Tail by
+// StreamLines and Lines.
+const DefaultTailLines = 100
+
+// maxLineLength is the longest line delivered in one piece. Longer lines are
+// delivered as several Lines.
+const maxLineLength = 64 << 10
+
+// Stream identifies the output stream a Line was read from.
+type Stream int
+
+const (
+	// Stdout is the standard output of the command.
+	Stdout Stream = iota + 1
+	// Stderr is the standard error output of the command.
+	Stderr
+)
+
+// String returns "stdout" or "stderr".
+func (s Stream) String() string {
+	switch s {
+	case Stdout:
+		return "stdout"
+	case Stderr:
+		return "stderr"
+	default:
+		return "unknown"
+	}
+}
+
+// Line is a line of command output, without its line terminator.
+type Line struct {
+	// Stream is the stream the line was written to.
+	Stream Stream
+	// Text is the content of the line.
+	Text string
+	// Time is when the line was read.
+	Time time.Time
+}
+
+// String formats the line for logs as "stream text".
+func (l Line) String() string {
+	return l.Stream.String() + " " + l.Text
+}
+
+// TailLines sets how many of the last output lines StreamLines and Lines keep in
+// Result.Tail for error reports, DefaultTailLines by default. Zero keeps none.
+func (c *Command) TailLines(n int) *Command {
+	c.tail = &n
+	return c
+}
+
+// StreamLines runs the command and calls fn with each stdout and stderr line as
+// soon as it is read. fn is called from a single goroutine, in the order lines are
+// read; a slow fn slows the command down rather than buffering output.
+//
+// Output is not accumulated: Result.Stdout and Result.Stderr are empty and
+// Result.Tail holds the last lines, as set by TailLines. Retries are not applied.
+//
+// Example:
+//
+//	res, err := cmd.NewCommand("./deploy.sh").Timeout(10 * time.Minute).
+//		StreamLines(func(l cmd.Line) {
+//			log.Printf("[%s] %s", l.Stream, l.Text)
+//		})
+//	if err != nil {
+//		for _, l := range res.Tail {
+//			log.Println(l)
+//		}
+//	}
+func (c *Command) StreamLines(fn func(Line)) (*Result, error) {
+	return c.stream(c.baseContext(), func(l Line) bool {
+		fn(l)
+		return true
+	})
+}
+
+// Lines runs the command and returns an iterator over its output lines, as read.
+// If the command fails, the iteration ends with a zero Line and the error.
+// Stopping the iteration early stops the command like a cancelled context.
+//
+// Example:
+//
+//	for line, err := range cmd.NewCommand("tail", "-f", "app.log").Lines() {
+//		if err != nil {
+//			return err
+//		}
+//		if strings.Contains(line.Text, "ready") {
+//			break
+//		}
+//	}
+func (c *Command) Lines() iter.Seq2[Line, error] {
+	return func(yield func(Line, error) bool) {
+		stopped := false
+		_, err := c.stream(c.baseContext(), func(l Line) bool {
+			stopped = !yield(l, nil)
+			return !stopped
+		})
+		if err != nil && !stopped {
+			yield(Line{}, err)
+		}
+	}
+}
+
+// stream runs the command under ctx, calling fn with each line until it returns
+// false, after which the command is stopped and the remaining output discarded.
+func (c *Command) stream(ctx context.Context, fn func(Line) bool) (*Result, error) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	runCtx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	tailSize := DefaultTailLines
+	if c.tail != nil {
+		tailSize = *c.tail
+	}
+	tail := newLineRing(tailSize)
+	res := &Result{Command: c.String(), ExitCode: -1, Attempts: 1}
+
+	spec := c.spec()
+	spec.Stdin = c.stdin
+	if c.stdinData != nil {
+		spec.Stdin = strings.NewReader(*c.stdinData)
+	}
+	stdout, stdoutWriter := io.Pipe()
+	spec.Stdout, spec.Stderr = stdoutWriter, stdoutWriter
+	var stderr *io.PipeReader
+	var stderrWriter *io.PipeWriter
+	if !c.combined {
+		stderr, stderrWriter = io.Pipe()
+		spec.Stderr = stderrWriter
+	}
+
+	executor := c.executor
+	if executor == nil {
+		executor = DefaultExecutor
+	}
+	start := time.Now()
+	proc, err := executor.Start(runCtx, spec)
+	if err != nil {
+		return res, err
+	}
+
+	// The pipes are closed once the command is done, ending the readers.
+	var exit Exit
+	go func() {
+		exit, err = proc.Wait()
+		_ = stdoutWriter.Close()
+		if stderrWriter != nil {
+			_ = stderrWriter.Close()
+		}
+	}()
+
+	lines := make(chan Line)
+	var readers sync.WaitGroup
+	readers.Add(1)
+	go readLines(stdout, Stdout, lines, &readers)
+	if stderr != nil {
+		readers.Add(1)
+		go readLines(stderr, Stderr, lines, &readers)
+	}
+	go func() {
+		readers.Wait()
+		close(lines)
+	}()
+
+	delivering := true
+	for line := range lines {
+		tail.add(line)
+		if delivering && !fn(line) {
+			delivering = false
+			stop()
+		}
+	}
+
+	res.ExitCode = exit.Code
+	res.Duration = time.Since(start)
+	res.Termination = exit.Termination
+	res.Tail = tail.lines()
+	if !delivering {
+		// Stopped by the caller: the resulting signal is not a failure.
+		return res, nil
+	}
+	return res, contextError(ctx, newExitError(res.Command, exit, err, tailStderr(res.Tail, c.combined), res.Duration))
+}
+
+// readLines sends the lines read from r to lines until EOF.
+func readLines(r io.Reader, stream Stream, lines chan<- Line, wg *sync.WaitGroup) {
+	defer wg.Done()
+	br := bufio.NewReaderSize(r, maxLineLength)
+	for {
+		text, _, err := br.ReadLine()
+		if len(text) > 0 || err == nil {
+			lines <- Line{Stream: stream, Text: string(text), Time: time.Now()}
+		}
+		if err != nil {
+			// Drain the pipe so the command never blocks writing to it.
+			_, _ = io.Copy(io.Discard, r)
+			return
+		}
+	}
+}
+
+// tailStderr joins the stderr lines of tail, or all of them for combined output.
+func tailStderr(tail []Line, combined bool) []byte {
+	var b []byte
+	for _, l := range tail {
+		if combined || l.Stream == Stderr {
+			b = append(append(b, l.Text...), '\n')
+		}
+	}
+	return b
+}
+
+// lineRing keeps the last lines added to it.
+type lineRing struct {
+	buf   []Line
+	next  int
+	total int
+}
+
+func newLineRing(size int) *lineRing {
+	return &lineRing{buf: make([]Line, max(size, 0))}
+}
+
+func (r *lineRing) add(l Line) {
+	if len(r.buf) == 0 {
+		return
+	}
+	r.buf[r.next] = l
+	r.next = (r.next + 1) % len(r.buf)
+	r.total++
+}
+
+// lines returns the kept lines, oldest first.
+func (r *lineRing) lines() []Line {
+	if r.total < len(r.buf) {
+		return append([]Line(nil), r.buf[:r.total]...)
+	}
+	return append(append([]Line(nil), r.buf[r.next:]...), r.buf[:r.next]...)
+}
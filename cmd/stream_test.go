@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestCommand_StreamLines 测试逐行回调与输出流标记
+func TestCommand_StreamLines(t *testing.T) {
+	var lines []Line
+	res, err := NewCommand("sh", "-c", `echo one; sleep 0.05; echo two >&2; sleep 0.05; printf 'three\r\n\nlast'`).
+		StreamLines(func(l Line) { lines = append(lines, l) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, l := range lines {
+		got = append(got, l.String())
+		if l.Time.IsZero() {
+			t.Error("Lines should be timestamped")
+		}
+	}
+	want := []string{"stdout one", "stderr two", "stdout three", "stdout ", "stdout last"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("Lines = %q, want %q", got, want)
+	}
+	if res.Stdout != "" || res.Stderr != "" || len(res.Tail) != 5 || res.ExitCode != 0 {
+		t.Errorf("Unexpected result %+v", res)
+	}
+}
+
+// TestCommand_StreamLinesTail 测试只保留最后N行用于错误报告
+func TestCommand_StreamLinesTail(t *testing.T) {
+	count := 0
+	res, err := NewCommand("sh", "-c", `for i in $(seq 1 1000); do echo "line $i"; done; sleep 0.1; echo failed >&2; exit 4`).
+		TailLines(3).
+		StreamLines(func(Line) { count++ })
+	if err == nil || res.ExitCode != 4 {
+		t.Errorf("Expected exit code 4, got %d %v", res.ExitCode, err)
+	}
+	if count != 1001 {
+		t.Errorf("Expected 1001 lines, got %d", count)
+	}
+	var tail []string
+	for _, l := range res.Tail {
+		tail = append(tail, l.Text)
+	}
+	if strings.Join(tail, ",") != "line 999,line 1000,failed" {
+		t.Errorf("Unexpected tail %q", tail)
+	}
+
+	res, _ = NewCommand("echo", "x").TailLines(0).StreamLines(func(Line) {})
+	if len(res.Tail) != 0 {
+		t.Errorf("Expected no tail, got %v", res.Tail)
+	}
+}
+
+// TestCommand_StreamLinesCombined 测试合并输出时所有行标记为stdout
+func TestCommand_StreamLinesCombined(t *testing.T) {
+	var streams []Stream
+	_, err := NewCommand("sh", "-c", "echo a; echo b >&2").CombinedOutput().
+		StreamLines(func(l Line) { streams = append(streams, l.Stream) })
+	if err != nil || len(streams) != 2 || streams[0] != Stdout || streams[1] != Stdout {
+		t.Errorf("Unexpected streams %v %v", streams, err)
+	}
+}
+
+// TestCommand_StreamLinesLongLine 测试超长行被分段投递
+func TestCommand_StreamLinesLongLine(t *testing.T) {
+	var total, pieces int
+	_, err := NewCommand("sh", "-c", "head -c 200000 /dev/zero | tr '\\0' a; echo").
+		StreamLines(func(l Line) {
+			total += len(l.Text)
+			pieces++
+		})
+	if err != nil || total != 200000 || pieces < 2 {
+		t.Errorf("Expected the long line in pieces, got %d bytes in %d lines %v", total, pieces, err)
+	}
+}
+
+// TestCommand_Lines 测试迭代器、提前退出与上下文取消
+func TestCommand_Lines(t *testing.T) {
+	var got []string
+	for line, err := range NewCommand("sh", "-c", "echo 1; echo 2; exit 5").Lines() {
+		if err != nil {
+			got = append(got, "err")
+			continue
+		}
+		got = append(got, line.Text)
+	}
+	if strings.Join(got, ",") != "1,2,err" {
+		t.Errorf("Unexpected iteration %v", got)
+	}
+
+	start := time.Now()
+	n := 0
+	for line, err := range NewCommand("sh", "-c", `i=0; while true; do i=$((i+1)); echo $i; sleep 0.01; done`).Lines() {
+		if err != nil {
+			t.Fatalf("Stopping early should not report an error: %v", err)
+		}
+		if n, _ = strconv.Atoi(line.Text); n == 3 {
+			break
+		}
+	}
+	if n != 3 || time.Since(start) > 3*time.Second {
+		t.Errorf("Expected the command to stop after 3 lines, got %d in %s", n, time.Since(start))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	var last error
+	for _, err := range NewCommand("sh", "-c", "echo started; sleep 5").Context(ctx).Lines() {
+		last = err
+	}
+	if !errors.Is(last, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", last)
+	}
+}
+
+// TestLineRing 测试环形缓冲区
+func TestLineRing(t *testing.T) {
+	r := newLineRing(3)
+	for i := 1; i <= 5; i++ {
+		r.add(Line{Text: strconv.Itoa(i)})
+	}
+	var got []string
+	for _, l := range r.lines() {
+		got = append(got, l.Text)
+	}
+	if strings.Join(got, ",") != "3,4,5" {
+		t.Errorf("lines() = %v", got)
+	}
+}
@@ -0,0 +1,375 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go4x/goal/retry"
+)
+
+// DefaultRestartWindow is the window in which restarts are counted for
+// MaxRestarts and for the backoff, unless another one is given.
+const DefaultRestartWindow = time.Minute
+
+var (
+	// ErrTooManyRestarts is returned by Supervisor.Wait when the process exited
+	// more often than allowed by MaxRestarts.
+	ErrTooManyRestarts = errors.New("too many restarts")
+	// ErrUnhealthy is reported for a process restarted because its health check
+	// kept failing.
+	ErrUnhealthy = errors.New("health check failed")
+	// ErrSupervisorStarted is returned when starting a Supervisor twice.
+	ErrSupervisorStarted = errors.New("supervisor already started")
+)
+
+// RestartPolicy tells when a supervised process is restarted.
+type RestartPolicy int
+
+const (
+	// RestartOnFailure restarts the process when it exits with a non-zero code,
+	// is killed by a signal or is unhealthy.
+	RestartOnFailure RestartPolicy = iota
+	// RestartAlways restarts the process whenever it exits.
+	RestartAlways
+	// RestartNever runs the process once.
+	RestartNever
+)
+
+// String returns "on-failure", "always" or "never".
+func (p RestartPolicy) String() string {
+	switch p {
+	case RestartOnFailure:
+		return "on-failure"
+	case RestartAlways:
+		return "always"
+	case RestartNever:
+		return "never"
+	default:
+		return fmt.Sprintf("RestartPolicy(%d)", int(p))
+	}
+}
+
+// HealthCheck describes how a supervised process is probed while it runs.
+type HealthCheck struct {
+	// Check probes the process, returning an error if it is unhealthy. It should
+	// return once ctx is done.
+	Check func(ctx context.Context) error
+	// Interval is the time between checks, 10 seconds if zero.
+	Interval time.Duration
+	// Timeout limits each check, Interval if zero.
+	Timeout time.Duration
+	// Failures is the number of consecutive failed checks after which the
+	// process is stopped and restarted as failed, 3 if zero.
+	Failures int
+	// StartPeriod delays the first check after each start, Interval if zero.
+	StartPeriod time.Duration
+}
+
+// Supervisor keeps a long-running process alive, restarting it with backoff
+// according to its RestartPolicy, RestartOnFailure by default.
+//
+// Output is streamed line by line to the function set with OnLine instead of
+// being accumulated, so the process may run indefinitely; each Result passed to
+// OnExit keeps the last lines in Tail.
+//
+// Example:
+//
+//	sup := cmd.NewSupervisor(cmd.NewCommand("./envoy", "-c", "envoy.yaml")).
+//		Policy(cmd.RestartAlways).
+//		Backoff(retry.ExponentialBackoffWithJitter(time.Second, 0.2)).
+//		MaxRestarts(5, time.Minute).
+//		HealthCheck(cmd.HealthCheck{Check: ping, Interval: 5 * time.Second}).
+//		OnLine(func(l cmd.Line) { log.Printf("envoy: %s", l.Text) })
+//	if err := sup.Start(); err != nil {
+//		return err
+//	}
+//	defer sup.Stop(context.Background())
+type Supervisor struct {
+	command     *Command
+	policy      RestartPolicy
+	interval    retry.Intervaler
+	maxRestarts int
+	window      time.Duration
+	health      HealthCheck
+	ctx         context.Context
+	onLine      func(Line)
+	onExit      func(*Result, error)
+
+	mu       sync.Mutex
+	cancel   context.CancelFunc
+	done     chan struct{}
+	err      error
+	restarts int
+}
+
+// NewSupervisor creates a Supervisor for command. The command's timeout limits
+// each run; its retries are not used.
+func NewSupervisor(command *Command) *Supervisor {
+	return &Supervisor{command: command, window: DefaultRestartWindow}
+}
+
+// Policy sets when the process is restarted.
+func (s *Supervisor) Policy(policy RestartPolicy) *Supervisor {
+	s.policy = policy
+	return s
+}
+
+// Backoff sets how long to wait before a restart, retry.DefaultInterval() if
+// nil. The interval is called with the number of restarts in the current
+// window, so quick successive exits back off further.
+func (s *Supervisor) Backoff(interval retry.Intervaler) *Supervisor {
+	s.interval = interval
+	return s
+}
+
+// MaxRestarts makes the supervisor give up with ErrTooManyRestarts when the
+// process would be restarted more than n times within window,
+// DefaultRestartWindow if zero. Zero n means no limit.
+func (s *Supervisor) MaxRestarts(n int, window time.Duration) *Supervisor {
+	s.maxRestarts = n
+	s.window = window
+	if window <= 0 {
+		s.window = DefaultRestartWindow
+	}
+	return s
+}
+
+// HealthCheck probes the process while it runs. When the check fails
+// hc.Failures times in a row, the process is stopped and handled as failed
+// with an error wrapping ErrUnhealthy.
+func (s *Supervisor) HealthCheck(hc HealthCheck) *Supervisor {
+	if hc.Interval <= 0 {
+		hc.Interval = 10 * time.Second
+	}
+	if hc.Timeout <= 0 {
+		hc.Timeout = hc.Interval
+	}
+	if hc.Failures <= 0 {
+		hc.Failures = 3
+	}
+	if hc.StartPeriod <= 0 {
+		hc.StartPeriod = hc.Interval
+	}
+	s.health = hc
+	return s
+}
+
+// Context sets a context that stops the supervisor when done, like Stop.
+func (s *Supervisor) Context(ctx context.Context) *Supervisor {
+	s.ctx = ctx
+	return s
+}
+
+// OnLine sets a function called with each output line of the process, from a
+// single goroutine.
+func (s *Supervisor) OnLine(fn func(Line)) *Supervisor {
+	s.onLine = fn
+	return s
+}
+
+// OnExit sets a function called after each run of the process with its result
+// and error, before deciding whether to restart it.
+func (s *Supervisor) OnExit(fn func(*Result, error)) *Supervisor {
+	s.onExit = fn
+	return s
+}
+
+// Start starts the process and supervises it in the background until Stop is
+// called, the context is done or the policy does not restart it.
+func (s *Supervisor) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.done != nil {
+		return ErrSupervisorStarted
+	}
+	ctx := s.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.done = make(chan struct{})
+	go s.supervise(ctx)
+	return nil
+}
+
+// Stop stops restarting the process and terminates it, giving it the grace
+// period of its command to exit, then waits until it is done. If ctx is done
+// first, Stop returns its error; the process is still killed once the grace
+// period has elapsed.
+func (s *Supervisor) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	cancel, done := s.cancel, s.done
+	s.mu.Unlock()
+	if done == nil {
+		return nil
+	}
+	cancel()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Wait waits until the supervisor is done. It returns nil if it was stopped or
+// the process exited without being restarted after a success, and otherwise
+// the error of the last run, wrapped with ErrTooManyRestarts if applicable.
+func (s *Supervisor) Wait() error {
+	done := s.Done()
+	if done == nil {
+		return nil
+	}
+	<-done
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Done returns a channel closed when the supervisor is done, or nil if it was
+// not started.
+func (s *Supervisor) Done() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.done
+}
+
+// Restarts returns the number of times the process was restarted.
+func (s *Supervisor) Restarts() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.restarts
+}
+
+// supervise runs the process until ctx is done or it is not restarted.
+func (s *Supervisor) supervise(ctx context.Context) {
+	var err error
+	defer func() {
+		s.mu.Lock()
+		s.err = err
+		s.cancel()
+		close(s.done)
+		s.mu.Unlock()
+	}()
+
+	interval := s.interval
+	if interval == nil {
+		interval = retry.DefaultInterval()
+	}
+	var restarts []time.Time // restarts within the window, oldest first
+	for {
+		var res *Result
+		res, err = s.run(ctx)
+		if s.onExit != nil {
+			s.onExit(res, err)
+		}
+		if ctx.Err() != nil {
+			err = nil
+			return
+		}
+		if s.policy == RestartNever || s.policy == RestartOnFailure && err == nil {
+			return
+		}
+
+		now := time.Now()
+		for len(restarts) > 0 && now.Sub(restarts[0]) >= s.window {
+			restarts = restarts[1:]
+		}
+		if s.maxRestarts > 0 && len(restarts) >= s.maxRestarts {
+			err = fmt.Errorf("%w: %d in %s: %w", ErrTooManyRestarts, len(restarts), s.window, err)
+			return
+		}
+		restarts = append(restarts, now)
+		if !sleep(ctx, interval, uint(len(restarts))) {
+			err = nil
+			return
+		}
+		s.mu.Lock()
+		s.restarts++
+		s.mu.Unlock()
+	}
+}
+
+// run runs the process once, stopping it if its health check keeps failing.
+func (s *Supervisor) run(ctx context.Context) (*Result, error) {
+	if s.command.ctx != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		defer cancel()
+		defer context.AfterFunc(s.command.ctx, cancel)()
+	}
+	runCtx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	var unhealthy error
+	var checks sync.WaitGroup
+	if s.health.Check != nil {
+		checks.Add(1)
+		go func() {
+			defer checks.Done()
+			if unhealthy = s.watch(runCtx); unhealthy != nil {
+				stop()
+			}
+		}()
+	}
+
+	res, err := s.command.stream(runCtx, func(l Line) bool {
+		if s.onLine != nil {
+			s.onLine(l)
+		}
+		return true
+	})
+	stop()
+	checks.Wait()
+	if unhealthy != nil && ctx.Err() == nil {
+		err = fmt.Errorf("%w: %w", ErrUnhealthy, unhealthy)
+	}
+	return res, err
+}
+
+// watch runs the health check until ctx is done, and returns the last error
+// once it failed too many times in a row.
+func (s *Supervisor) watch(ctx context.Context) error {
+	hc := s.health
+	timer := time.NewTimer(hc.StartPeriod)
+	defer timer.Stop()
+	failures := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-timer.C:
+		}
+		checkCtx, cancel := context.WithTimeout(ctx, hc.Timeout)
+		err := hc.Check(checkCtx)
+		cancel()
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err == nil {
+			failures = 0
+		} else if failures++; failures >= hc.Failures {
+			return err
+		}
+		timer.Reset(hc.Interval)
+	}
+}
+
+// sleep waits for interval.Interval(n), returning false if ctx is done first.
+// The interval cannot be interrupted, so it finishes in the background.
+func sleep(ctx context.Context, interval retry.Intervaler, n uint) bool {
+	slept := make(chan struct{})
+	go func() {
+		interval.Interval(n)
+		close(slept)
+	}()
+	select {
+	case <-slept:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go4x/goal/retry"
+)
+
+// TestSupervisor_RestartOnFailure 测试失败后按退避重启并限制重启次数
+func TestSupervisor_RestartOnFailure(t *testing.T) {
+	var mu sync.Mutex
+	var lines []string
+	var exits []int
+	sup := NewSupervisor(NewCommand("sh", "-c", "echo run; exit 3")).
+		Backoff(retry.ConstantInterval(10*time.Millisecond)).
+		MaxRestarts(2, time.Minute).
+		OnLine(func(l Line) {
+			mu.Lock()
+			defer mu.Unlock()
+			lines = append(lines, l.Text)
+		}).
+		OnExit(func(res *Result, err error) {
+			exits = append(exits, res.ExitCode)
+		})
+	if err := sup.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if err := sup.Start(); !errors.Is(err, ErrSupervisorStarted) {
+		t.Errorf("Expected ErrSupervisorStarted, got %v", err)
+	}
+
+	err := sup.Wait()
+	var exitErr *ExitError
+	if !errors.Is(err, ErrTooManyRestarts) || !errors.As(err, &exitErr) || exitErr.ExitCode != 3 {
+		t.Fatalf("Expected too many restarts of an exit 3, got %v", err)
+	}
+	if !strings.HasPrefix(err.Error(), "too many restarts: 2 in 1m0s: sh -c") {
+		t.Errorf("Unexpected message %q", err)
+	}
+	if sup.Restarts() != 2 || len(exits) != 3 || strings.Join(lines, ",") != "run,run,run" {
+		t.Errorf("Expected 3 runs, got %d restarts, exits %v, lines %v", sup.Restarts(), exits, lines)
+	}
+}
+
+// TestSupervisor_Policy 测试不同的重启策略
+func TestSupervisor_Policy(t *testing.T) {
+	fast := retry.ConstantInterval(time.Millisecond)
+
+	sup := NewSupervisor(NewCommand("true")).Backoff(fast)
+	if err := sup.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := sup.Wait(); err != nil || sup.Restarts() != 0 {
+		t.Errorf("on-failure should not restart a success, got %v after %d restarts", err, sup.Restarts())
+	}
+
+	sup = NewSupervisor(NewCommand("false")).Policy(RestartNever).Backoff(fast)
+	_ = sup.Start()
+	if err := sup.Wait(); err == nil || sup.Restarts() != 0 {
+		t.Errorf("never should run once and report the failure, got %v after %d restarts", err, sup.Restarts())
+	}
+
+	sup = NewSupervisor(NewCommand("true")).Policy(RestartAlways).Backoff(fast).MaxRestarts(3, 0)
+	_ = sup.Start()
+	if err := sup.Wait(); !errors.Is(err, ErrTooManyRestarts) || sup.Restarts() != 3 {
+		t.Errorf("always should restart a success, got %v after %d restarts", err, sup.Restarts())
+	}
+
+	if RestartAlways.String() != "always" || RestartOnFailure.String() != "on-failure" || RestartNever.String() != "never" {
+		t.Error("Unexpected policy names")
+	}
+	if err := NewSupervisor(NewCommand("true")).Wait(); err != nil {
+		t.Errorf("Wait before Start should return nil, got %v", err)
+	}
+}
+
+// TestSupervisor_Stop 测试优雅停止运行中或等待重启的进程
+func TestSupervisor_Stop(t *testing.T) {
+	var results []*Result
+	sup := NewSupervisor(NewCommand("sleep", "5")).
+		Policy(RestartAlways).
+		OnExit(func(res *Result, err error) { results = append(results, res) })
+	_ = sup.Start()
+	time.Sleep(100 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	start := time.Now()
+	if err := sup.Stop(ctx); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Errorf("Expected a prompt stop, took %s", time.Since(start))
+	}
+	if err := sup.Wait(); err != nil || sup.Restarts() != 0 {
+		t.Errorf("A stopped supervisor should end without error, got %v", err)
+	}
+	if len(results) != 1 || results[0].Termination != Terminated {
+		t.Errorf("Expected the process to be terminated once, got %+v", results)
+	}
+
+	sup = NewSupervisor(NewCommand("false")).Backoff(retry.ConstantInterval(time.Minute))
+	_ = sup.Start()
+	time.Sleep(100 * time.Millisecond)
+	start = time.Now()
+	if err := sup.Stop(ctx); err != nil || time.Since(start) > time.Second {
+		t.Errorf("Expected Stop to interrupt the backoff, got %v after %s", err, time.Since(start))
+	}
+
+	parent, cancelParent := context.WithCancel(context.Background())
+	sup = NewSupervisor(NewCommand("sleep", "5")).Context(parent)
+	_ = sup.Start()
+	cancelParent()
+	select {
+	case <-sup.Done():
+	case <-time.After(2 * time.Second):
+		t.Error("Expected the supervisor to stop with its context")
+	}
+}
+
+// TestSupervisor_HealthCheck 测试健康检查连续失败后重启进程
+func TestSupervisor_HealthCheck(t *testing.T) {
+	var mu sync.Mutex
+	checks := 0
+	sup := NewSupervisor(NewCommand("sleep", "5")).
+		Backoff(retry.ConstantInterval(time.Millisecond)).
+		MaxRestarts(1, time.Minute).
+		HealthCheck(HealthCheck{
+			Check: func(ctx context.Context) error {
+				mu.Lock()
+				defer mu.Unlock()
+				checks++
+				return errors.New("connection refused")
+			},
+			Interval: 20 * time.Millisecond,
+			Failures: 2,
+		})
+	start := time.Now()
+	_ = sup.Start()
+	err := sup.Wait()
+	if !errors.Is(err, ErrTooManyRestarts) || !errors.Is(err, ErrUnhealthy) || !strings.Contains(err.Error(), "connection refused") {
+		t.Fatalf("Expected an unhealthy process to be given up, got %v", err)
+	}
+	if time.Since(start) > 3*time.Second {
+		t.Errorf("Expected unhealthy processes to be stopped, took %s", time.Since(start))
+	}
+	if sup.Restarts() != 1 || checks != 4 {
+		t.Errorf("Expected 2 runs of 2 checks, got %d restarts and %d checks", sup.Restarts(), checks)
+	}
+}
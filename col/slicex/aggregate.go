@@ -0,0 +1,237 @@
+package slicex
+
+import (
+	"cmp"
+	"errors"
+	"math"
+	"slices"
+)
+
+// ErrOverflow is returned by SumChecked when the sum does not fit in the
+// element type.
+var ErrOverflow = errors.New("slicex: integer overflow")
+
+// Signed is a constraint permitting any signed integer type.
+type Signed interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64
+}
+
+// Unsigned is a constraint permitting any unsigned integer type.
+type Unsigned interface {
+	~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// Integer is a constraint permitting any integer type.
+type Integer interface {
+	Signed | Unsigned
+}
+
+// Float is a constraint permitting any floating-point type.
+type Float interface {
+	~float32 | ~float64
+}
+
+// Number is a constraint permitting any integer or floating-point type.
+type Number interface {
+	Integer | Float
+}
+
+// Max returns the largest element of the slice, or the zero value if it is
+// empty. For floating-point numbers, a NaN element makes the result NaN.
+//
+// Example:
+//
+//	slicex.Max([]int{9, 10, 3})          // 10
+//	slicex.Max([]string{"b", "c", "a"}) // "c"
+func Max[T cmp.Ordered](slice []T) T {
+	if len(slice) == 0 {
+		var zero T
+		return zero
+	}
+	return slices.Max(slice)
+}
+
+// Min returns the smallest element of the slice, or the zero value if it is
+// empty. For floating-point numbers, a NaN element makes the result NaN.
+//
+// Example:
+//
+//	slicex.Min([]int{9, 10, 3}) // 3
+func Min[T cmp.Ordered](slice []T) T {
+	if len(slice) == 0 {
+		var zero T
+		return zero
+	}
+	return slices.Min(slice)
+}
+
+// MaxBy returns the first element with the largest key, or the zero value if
+// the slice is empty.
+//
+// Example:
+//
+//	oldest := slicex.MaxBy(users, func(u User) int { return u.Age })
+func MaxBy[T any, K cmp.Ordered](slice []T, key func(T) K) T {
+	return extremeBy(slice, key, 1)
+}
+
+// MinBy returns the first element with the smallest key, or the zero value if
+// the slice is empty.
+//
+// Example:
+//
+//	cheapest := slicex.MinBy(products, func(p Product) float64 { return p.Price })
+func MinBy[T any, K cmp.Ordered](slice []T, key func(T) K) T {
+	return extremeBy(slice, key, -1)
+}
+
+// extremeBy returns the first element whose key compares to all others with
+// the sign of want, or as equal.
+func extremeBy[T any, K cmp.Ordered](slice []T, key func(T) K, want int) T {
+	var best T
+	var bestKey K
+	for i, v := range slice {
+		k := key(v)
+		if i == 0 || cmp.Compare(k, bestKey) == want {
+			best, bestKey = v, k
+		}
+	}
+	return best
+}
+
+// Sum returns the sum of the elements of the slice, 0 if it is empty. Integer
+// sums wrap around on overflow like the + operator; use SumChecked to detect it.
+//
+// Example:
+//
+//	slicex.Sum([]int{1, 2, 3})         // 6
+//	slicex.Sum([]float64{0.5, 0.25}) // 0.75
+func Sum[T Number](slice []T) T {
+	var sum T
+	for _, v := range slice {
+		sum += v
+	}
+	return sum
+}
+
+// SumChecked returns the sum of the integers of the slice, or ErrOverflow if it
+// does not fit in their type.
+//
+// Example:
+//
+//	total, err := slicex.SumChecked([]int8{100, 27}) // 127, nil
+//	total, err = slicex.SumChecked([]int8{100, 28})  // 0, ErrOverflow
+func SumChecked[T Integer](slice []T) (T, error) {
+	var sum T
+	for _, v := range slice {
+		next := sum + v
+		if v > 0 && next < sum || v < 0 && next > sum {
+			return 0, ErrOverflow
+		}
+		sum = next
+	}
+	return sum, nil
+}
+
+// SumBy returns the sum of the keys of the elements of the slice.
+//
+// Example:
+//
+//	total := slicex.SumBy(items, func(i Item) float64 { return i.Price * float64(i.Quantity) })
+func SumBy[T any, N Number](slice []T, key func(T) N) N {
+	var sum N
+	for _, v := range slice {
+		sum += key(v)
+	}
+	return sum
+}
+
+// Average returns the arithmetic mean of the slice, 0 if it is empty. It is
+// computed in float64, so integer elements cannot overflow.
+//
+// Example:
+//
+//	slicex.Average([]int{1, 2, 3, 4}) // 2.5
+func Average[T Number](slice []T) float64 {
+	if len(slice) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range slice {
+		sum += float64(v)
+	}
+	return sum / float64(len(slice))
+}
+
+// Median returns the middle value of the slice, the mean of the two middle
+// values for an even length, or 0 if it is empty. The slice is not modified.
+//
+// Example:
+//
+//	slicex.Median([]int{7, 1, 3})    // 3
+//	slicex.Median([]int{7, 1, 3, 4}) // 3.5
+func Median[T Number](slice []T) float64 {
+	return Percentile(slice, 50)
+}
+
+// Percentile returns the p-th percentile of the slice, p being clamped to
+// [0, 100], or 0 if the slice is empty; a NaN p returns NaN. Values between
+// two elements are linearly interpolated, like PERCENTILE.INC in spreadsheets
+// and the default method of NumPy. The slice is not modified.
+//
+// Example:
+//
+//	latencies := []int{12, 15, 20, 22, 30, 95}
+//	p90 := slicex.Percentile(latencies, 90) // 62.5
+func Percentile[T Number](slice []T, p float64) float64 {
+	if len(slice) == 0 {
+		return 0
+	}
+	if math.IsNaN(p) {
+		return math.NaN()
+	}
+	sorted := make([]float64, len(slice))
+	for i, v := range slice {
+		sorted[i] = float64(v)
+	}
+	slices.Sort(sorted)
+
+	rank := min(max(p, 0), 100) / 100 * float64(len(sorted)-1)
+	lower := int(rank)
+	if lower == len(sorted)-1 {
+		return sorted[lower]
+	}
+	return sorted[lower] + (rank-float64(lower))*(sorted[lower+1]-sorted[lower])
+}
+
+// Variance returns the population variance of the slice, the mean of the
+// squared deviations from the average, or 0 if it is empty. For the sample
+// variance, multiply it by n/(n-1).
+//
+// Example:
+//
+//	slicex.Variance([]int{2, 4, 4, 4, 5, 5, 7, 9}) // 4
+func Variance[T Number](slice []T) float64 {
+	if len(slice) == 0 {
+		return 0
+	}
+	// Welford's algorithm avoids the cancellation of the naive formula.
+	var mean, m2 float64
+	for i, v := range slice {
+		x := float64(v)
+		delta := x - mean
+		mean += delta / float64(i+1)
+		m2 += delta * (x - mean)
+	}
+	return m2 / float64(len(slice))
+}
+
+// StdDev returns the population standard deviation of the slice, the square
+// root of its Variance, or 0 if it is empty.
+//
+// Example:
+//
+//	slicex.StdDev([]int{2, 4, 4, 4, 5, 5, 7, 9}) // 2
+func StdDev[T Number](slice []T) float64 {
+	return math.Sqrt(Variance(slice))
+}
@@ -0,0 +1,155 @@
+package slicex_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/go4x/goal/col/slicex"
+)
+
+type product struct {
+	Name  string
+	Price float64
+	Stock int
+}
+
+var products = []product{
+	{"pen", 1.5, 100},
+	{"book", 12, 8},
+	{"lamp", 30, 8},
+	{"mug", 6.25, 20},
+}
+
+// TestMaxMin tests Max and Min with numeric and string ordering
+func TestMaxMin(t *testing.T) {
+	if got := slicex.Max([]int{9, 10, 3}); got != 10 {
+		t.Errorf("Max should compare numerically, expected 10, got %d", got)
+	}
+	if got := slicex.Min([]int{9, 10, -3}); got != -3 {
+		t.Errorf("Min should compare numerically, expected -3, got %d", got)
+	}
+	if got := slicex.Max([]string{"b", "c", "a"}); got != "c" {
+		t.Errorf("Max of strings, expected c, got %s", got)
+	}
+	if got := slicex.Min([]float64{2.5, 0.1, 1e9}); got != 0.1 {
+		t.Errorf("Min of floats, expected 0.1, got %v", got)
+	}
+	if slicex.Max([]int{}) != 0 || slicex.Min[string](nil) != "" {
+		t.Error("Max and Min of an empty slice should return the zero value")
+	}
+	if !math.IsNaN(slicex.Max([]float64{1, math.NaN(), 2})) {
+		t.Error("Max should propagate NaN")
+	}
+}
+
+// TestMaxByMinBy tests MaxBy and MinBy with key functions
+func TestMaxByMinBy(t *testing.T) {
+	if got := slicex.MaxBy(products, func(p product) float64 { return p.Price }); got.Name != "lamp" {
+		t.Errorf("MaxBy price, expected lamp, got %s", got.Name)
+	}
+	if got := slicex.MinBy(products, func(p product) int { return p.Stock }); got.Name != "book" {
+		t.Errorf("MinBy should return the first of equal keys, expected book, got %s", got.Name)
+	}
+	if got := slicex.MaxBy(products, func(p product) string { return p.Name }); got.Name != "pen" {
+		t.Errorf("MaxBy name, expected pen, got %s", got.Name)
+	}
+	if got := slicex.MinBy([]product{}, func(p product) int { return p.Stock }); got != (product{}) {
+		t.Errorf("MinBy of an empty slice should return the zero value, got %+v", got)
+	}
+}
+
+// TestSum tests Sum, SumBy and SumChecked
+func TestSum(t *testing.T) {
+	if got := slicex.Sum([]int{1, 2, 3}); got != 6 {
+		t.Errorf("Sum, expected 6, got %d", got)
+	}
+	if got := slicex.Sum([]float64{0.5, 0.25}); got != 0.75 {
+		t.Errorf("Sum of floats, expected 0.75, got %v", got)
+	}
+	if got := slicex.Sum([]uint8{}); got != 0 {
+		t.Errorf("Sum of an empty slice, expected 0, got %d", got)
+	}
+	if got := slicex.SumBy(products, func(p product) int { return p.Stock }); got != 136 {
+		t.Errorf("SumBy, expected 136, got %d", got)
+	}
+
+	tests := []struct {
+		name string
+		sum  func() (int64, error)
+		want int64
+		err  error
+	}{
+		{"int8 max", func() (int64, error) { s, err := slicex.SumChecked([]int8{100, 27}); return int64(s), err }, 127, nil},
+		{"int8 overflow", func() (int64, error) { s, err := slicex.SumChecked([]int8{100, 28}); return int64(s), err }, 0, slicex.ErrOverflow},
+		{"int8 underflow", func() (int64, error) { s, err := slicex.SumChecked([]int8{-100, -29}); return int64(s), err }, 0, slicex.ErrOverflow},
+		{"int8 back in range", func() (int64, error) { s, err := slicex.SumChecked([]int8{100, 27, -100, 50}); return int64(s), err }, 77, nil},
+		{"uint8 overflow", func() (int64, error) { s, err := slicex.SumChecked([]uint8{200, 56}); return int64(s), err }, 0, slicex.ErrOverflow},
+		{"int64 overflow", func() (int64, error) { return slicex.SumChecked([]int64{math.MaxInt64, 1}) }, 0, slicex.ErrOverflow},
+		{"int64 min", func() (int64, error) { return slicex.SumChecked([]int64{math.MinInt64 + 1, -1}) }, math.MinInt64, nil},
+	}
+	for _, tt := range tests {
+		got, err := tt.sum()
+		if got != tt.want || !errors.Is(err, tt.err) {
+			t.Errorf("SumChecked %s, expected %d %v, got %d %v", tt.name, tt.want, tt.err, got, err)
+		}
+	}
+}
+
+// TestStatistics tests Average, Median, Percentile, Variance and StdDev
+func TestStatistics(t *testing.T) {
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+	if got := slicex.Average([]int{1, 2, 3, 4}); got != 2.5 {
+		t.Errorf("Average, expected 2.5, got %v", got)
+	}
+	if got := slicex.Average([]int64{math.MaxInt64, math.MaxInt64}); got != math.MaxInt64 {
+		t.Errorf("Average should not overflow, got %v", got)
+	}
+
+	values := []int{7, 1, 3, 4}
+	if got := slicex.Median(values); got != 3.5 {
+		t.Errorf("Median of an even length, expected 3.5, got %v", got)
+	}
+	if got := slicex.Median(values[:3]); got != 3 {
+		t.Errorf("Median of an odd length, expected 3, got %v", got)
+	}
+	if values[0] != 7 || values[1] != 1 {
+		t.Error("Median should not sort the slice")
+	}
+
+	latencies := []float64{12, 15, 20, 22, 30, 95}
+	percentiles := map[float64]float64{0: 12, 25: 16.25, 50: 21, 90: 62.5, 100: 95, -5: 12, 150: 95}
+	for p, want := range percentiles {
+		if got := slicex.Percentile(latencies, p); !near(got, want) {
+			t.Errorf("Percentile %v, expected %v, got %v", p, want, got)
+		}
+	}
+	if !math.IsNaN(slicex.Percentile(latencies, math.NaN())) {
+		t.Error("Percentile of NaN should be NaN")
+	}
+
+	data := []int{2, 4, 4, 4, 5, 5, 7, 9}
+	if got := slicex.Variance(data); !near(got, 4) {
+		t.Errorf("Variance, expected 4, got %v", got)
+	}
+	if got := slicex.StdDev(data); !near(got, 2) {
+		t.Errorf("StdDev, expected 2, got %v", got)
+	}
+	shifted := []float64{1e9 + 4, 1e9 + 7, 1e9 + 13, 1e9 + 16}
+	if got := slicex.Variance(shifted); !near(got, 22.5) {
+		t.Errorf("Variance should be numerically stable, expected 22.5, got %v", got)
+	}
+
+	for name, got := range map[string]float64{
+		"Average":    slicex.Average([]int{}),
+		"Median":     slicex.Median([]int{}),
+		"Percentile": slicex.Percentile([]int{}, 50),
+		"Variance":   slicex.Variance([]int{}),
+		"StdDev":     slicex.StdDev([]int{}),
+	} {
+		if got != 0 {
+			t.Errorf("%s of an empty slice, expected 0, got %v", name, got)
+		}
+	}
+}
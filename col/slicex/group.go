@@ -0,0 +1,161 @@
+package slicex
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/go4x/goal/col/mapx"
+)
+
+// ErrDuplicateKey is returned by KeyBy when two elements have the same key.
+var ErrDuplicateKey = errors.New("slicex: duplicate key")
+
+// GroupBy groups the elements of the slice by key. Unlike Group, every element
+// is kept: each key maps to the elements having it, in their original order,
+// and the keys are ordered by first occurrence.
+//
+// Example:
+//
+//	byCountry := slicex.GroupBy(users, func(u User) string { return u.Country })
+//	byCountry.Each(func(country string, users []User) {
+//		fmt.Println(country, len(users))
+//	})
+func GroupBy[T any, K comparable](slice []T, key func(T) K) *mapx.LinkedMap[K, []T] {
+	groups := mapx.NewLinkedMap[K, []T]()
+	for _, v := range slice {
+		k := key(v)
+		group, _ := groups.Get(k)
+		groups.Put(k, append(group, v))
+	}
+	return groups
+}
+
+// CountBy counts the elements of the slice per key, the keys being ordered by
+// first occurrence.
+//
+// Example:
+//
+//	byStatus := slicex.CountBy(orders, func(o Order) string { return o.Status })
+//	pending, _ := byStatus.Get("pending")
+func CountBy[T any, K comparable](slice []T, key func(T) K) *mapx.LinkedMap[K, int] {
+	counts := mapx.NewLinkedMap[K, int]()
+	for _, v := range slice {
+		k := key(v)
+		n, _ := counts.Get(k)
+		counts.Put(k, n+1)
+	}
+	return counts
+}
+
+// Frequencies counts the occurrences of each distinct element of the slice,
+// the elements being ordered by first occurrence.
+//
+// Example:
+//
+//	slicex.Frequencies([]string{"b", "a", "b"}) // map[b:2 a:1]
+func Frequencies[T comparable](slice []T) *mapx.LinkedMap[T, int] {
+	return CountBy(slice, func(v T) T { return v })
+}
+
+// KeyBy maps each element of the slice to its key, in the order of the slice.
+// Unlike Group, which keeps the last element silently, it returns an error
+// wrapping ErrDuplicateKey if two elements have the same key.
+//
+// Example:
+//
+//	byID, err := slicex.KeyBy(users, func(u User) int { return u.ID })
+//	if err != nil {
+//		return err // slicex: duplicate key: 42
+//	}
+func KeyBy[T any, K comparable](slice []T, key func(T) K) (*mapx.LinkedMap[K, T], error) {
+	keyed := mapx.NewLinkedMap[K, T]()
+	for _, v := range slice {
+		k := key(v)
+		if keyed.Contains(k) {
+			return nil, fmt.Errorf("%w: %v", ErrDuplicateKey, k)
+		}
+		keyed.Put(k, v)
+	}
+	return keyed, nil
+}
+
+// IndexBy maps each key to the index of the first element of the slice having
+// it, for lookups that need the position of an element, such as joins.
+//
+// Example:
+//
+//	index := slicex.IndexBy(rows, func(r Row) string { return r.SKU })
+//	if i, ok := index["A-100"]; ok {
+//		rows[i].Stock += 10
+//	}
+func IndexBy[T any, K comparable](slice []T, key func(T) K) map[K]int {
+	index := make(map[K]int, len(slice))
+	for i, v := range slice {
+		k := key(v)
+		if _, ok := index[k]; !ok {
+			index[k] = i
+		}
+	}
+	return index
+}
+
+// PartitionBy splits the slice into runs of consecutive elements having the
+// same key. On a slice sorted by key, each run is a complete group.
+//
+// Example:
+//
+//	slicex.PartitionBy([]int{1, 3, 2, 4, 5}, func(n int) bool { return n%2 == 0 })
+//	// [[1 3] [2 4] [5]]
+func PartitionBy[T any, K comparable](slice []T, key func(T) K) [][]T {
+	var runs [][]T
+	var last K
+	for i, v := range slice {
+		k := key(v)
+		if i == 0 || k != last {
+			runs = append(runs, nil)
+			last = k
+		}
+		runs[len(runs)-1] = append(runs[len(runs)-1], v)
+	}
+	return runs
+}
+
+// SortBy returns a copy of the slice sorted by the given comparisons, each one
+// breaking the ties of the previous ones. The sort is stable, so elements that
+// compare equal on every key keep their order. Asc and Desc build comparisons
+// from key functions.
+//
+// Example:
+//
+//	sorted := slicex.SortBy(users,
+//		slicex.Asc(func(u User) string { return u.Country }),
+//		slicex.Desc(func(u User) int { return u.Age }),
+//	)
+func SortBy[T any](slice []T, compares ...func(a, b T) int) []T {
+	sorted := slices.Clone(slice)
+	slices.SortStableFunc(sorted, func(a, b T) int {
+		for _, compare := range compares {
+			if c := compare(a, b); c != 0 {
+				return c
+			}
+		}
+		return 0
+	})
+	return sorted
+}
+
+// Asc returns a comparison ordering elements by ascending key, for SortBy.
+func Asc[T any, K cmp.Ordered](key func(T) K) func(a, b T) int {
+	return func(a, b T) int {
+		return cmp.Compare(key(a), key(b))
+	}
+}
+
+// Desc returns a comparison ordering elements by descending key, for SortBy.
+func Desc[T any, K cmp.Ordered](key func(T) K) func(a, b T) int {
+	return func(a, b T) int {
+		return cmp.Compare(key(b), key(a))
+	}
+}
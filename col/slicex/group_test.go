@@ -0,0 +1,134 @@
+package slicex_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/go4x/goal/col/slicex"
+)
+
+type sale struct {
+	Region  string
+	Product string
+	Amount  int
+}
+
+var sales = []sale{
+	{"west", "tea", 30},
+	{"east", "coffee", 50},
+	{"west", "coffee", 20},
+	{"north", "tea", 10},
+	{"east", "tea", 40},
+}
+
+// TestGroupBy tests the GroupBy function
+func TestGroupBy(t *testing.T) {
+	groups := slicex.GroupBy(sales, func(s sale) string { return s.Region })
+	if got := groups.Keys(); !reflect.DeepEqual(got, []string{"west", "east", "north"}) {
+		t.Errorf("GroupBy should order keys by first occurrence, got %v", got)
+	}
+	west, _ := groups.Get("west")
+	if !reflect.DeepEqual(west, []sale{sales[0], sales[2]}) {
+		t.Errorf("GroupBy should keep every element in order, got %v", west)
+	}
+
+	if empty := slicex.GroupBy([]sale{}, func(s sale) string { return s.Region }); !empty.IsEmpty() {
+		t.Errorf("GroupBy of an empty slice should be empty, got %v", empty)
+	}
+}
+
+// TestCountBy tests the CountBy and Frequencies functions
+func TestCountBy(t *testing.T) {
+	counts := slicex.CountBy(sales, func(s sale) string { return s.Product })
+	if got := counts.String(); got != "map[tea:3 coffee:2]" {
+		t.Errorf("CountBy, expected map[tea:3 coffee:2], got %s", got)
+	}
+
+	freq := slicex.Frequencies([]string{"b", "a", "b", "c", "b"})
+	if got := freq.String(); got != "map[b:3 a:1 c:1]" {
+		t.Errorf("Frequencies, expected map[b:3 a:1 c:1], got %s", got)
+	}
+	if n, ok := freq.Get("z"); ok || n != 0 {
+		t.Errorf("Frequencies should not contain absent elements, got %d", n)
+	}
+}
+
+// TestKeyBy tests the KeyBy function
+func TestKeyBy(t *testing.T) {
+	byProduct, err := slicex.KeyBy(sales[:2], func(s sale) string { return s.Product })
+	if err != nil {
+		t.Fatalf("KeyBy returned error: %v", err)
+	}
+	if got := byProduct.Keys(); !reflect.DeepEqual(got, []string{"tea", "coffee"}) {
+		t.Errorf("KeyBy should keep the order of the slice, got %v", got)
+	}
+	if s, _ := byProduct.Get("coffee"); s != sales[1] {
+		t.Errorf("KeyBy, expected %v, got %v", sales[1], s)
+	}
+
+	byProduct, err = slicex.KeyBy(sales, func(s sale) string { return s.Product })
+	if !errors.Is(err, slicex.ErrDuplicateKey) || byProduct != nil {
+		t.Fatalf("KeyBy should fail on duplicate keys, got %v, %v", byProduct, err)
+	}
+	if err.Error() != "slicex: duplicate key: coffee" {
+		t.Errorf("KeyBy error should name the key, got %q", err)
+	}
+}
+
+// TestIndexBy tests the IndexBy function
+func TestIndexBy(t *testing.T) {
+	index := slicex.IndexBy(sales, func(s sale) string { return s.Region })
+	expected := map[string]int{"west": 0, "east": 1, "north": 3}
+	if !reflect.DeepEqual(index, expected) {
+		t.Errorf("IndexBy should keep the first index, expected %v, got %v", expected, index)
+	}
+}
+
+// TestPartitionBy tests the PartitionBy function
+func TestPartitionBy(t *testing.T) {
+	runs := slicex.PartitionBy([]int{1, 3, 2, 4, 5, 7, 6}, func(n int) bool { return n%2 == 0 })
+	expected := [][]int{{1, 3}, {2, 4}, {5, 7}, {6}}
+	if !reflect.DeepEqual(runs, expected) {
+		t.Errorf("PartitionBy, expected %v, got %v", expected, runs)
+	}
+	if runs := slicex.PartitionBy([]int{}, func(n int) int { return n }); len(runs) != 0 {
+		t.Errorf("PartitionBy of an empty slice should be empty, got %v", runs)
+	}
+}
+
+// TestSortBy tests the SortBy, Asc and Desc functions
+func TestSortBy(t *testing.T) {
+	region := func(s sale) string { return s.Region }
+	amount := func(s sale) int { return s.Amount }
+
+	t.Run("multiple keys", func(t *testing.T) {
+		sorted := slicex.SortBy(sales, slicex.Asc(region), slicex.Desc(amount))
+		expected := []sale{sales[1], sales[4], sales[3], sales[0], sales[2]}
+		if !reflect.DeepEqual(sorted, expected) {
+			t.Errorf("SortBy, expected %v, got %v", expected, sorted)
+		}
+	})
+
+	t.Run("stable", func(t *testing.T) {
+		sorted := slicex.SortBy(sales, slicex.Asc(func(s sale) string { return s.Product }))
+		expected := []sale{sales[1], sales[2], sales[0], sales[3], sales[4]}
+		if !reflect.DeepEqual(sorted, expected) {
+			t.Errorf("SortBy should keep the order of equal elements, expected %v, got %v", expected, sorted)
+		}
+	})
+
+	t.Run("no keys", func(t *testing.T) {
+		if sorted := slicex.SortBy(sales); !reflect.DeepEqual(sorted, sales) {
+			t.Errorf("SortBy without keys should keep the order, got %v", sorted)
+		}
+	})
+
+	t.Run("original unchanged", func(t *testing.T) {
+		original := slicex.SortBy(sales)
+		slicex.SortBy(sales, slicex.Desc(amount))
+		if !reflect.DeepEqual(sales, original) {
+			t.Errorf("SortBy should not modify the slice, got %v", sales)
+		}
+	})
+}
@@ -1,7 +1,5 @@
 package slicex
 
-import "fmt"
-
 // basic functions
 
 // Equal reports whether two slices are equal: the same length and all
@@ -646,58 +644,6 @@ func Partition[T any](slice []T, predicate func(T) bool) ([]T, []T) {
 	return trueSlice, falseSlice
 }
 
-// Max returns the maximum value from the slice using string comparison.
-func Max[T comparable](slice []T) T {
-	if len(slice) == 0 {
-		var zero T
-		return zero
-	}
-	max := slice[0]
-	for _, v := range slice[1:] {
-		if fmt.Sprintf("%v", v) > fmt.Sprintf("%v", max) {
-			max = v
-		}
-	}
-	return max
-}
-
-// Min returns the minimum value from the slice using string comparison.
-func Min[T comparable](slice []T) T {
-	if len(slice) == 0 {
-		var zero T
-		return zero
-	}
-	min := slice[0]
-	for _, v := range slice[1:] {
-		if fmt.Sprintf("%v", v) < fmt.Sprintf("%v", min) {
-			min = v
-		}
-	}
-	return min
-}
-
-// Sum returns the sum of all values in the slice.
-// Note: This only works for numeric types. For other types, it returns the first element.
-func Sum[T comparable](slice []T) T {
-	if len(slice) == 0 {
-		var zero T
-		return zero
-	}
-	// For non-numeric types, just return the first element
-	// In practice, you'd need type-specific implementations
-	return slice[0]
-}
-
-// Average returns the average of all values in the slice.
-// Note: This is a simplified implementation for demonstration.
-func Average[T comparable](slice []T) float64 {
-	if len(slice) == 0 {
-		return 0
-	}
-	// This is a simplified implementation - in practice, you'd need type-specific handling
-	return float64(len(slice))
-}
-
 // Head returns the first element of the slice, or zero value if empty.
 func Head[T any](slice []T) T {
 	if len(slice) == 0 {
@@ -3,8 +3,10 @@ module github.com/go4x/goal
 go 1.24.0
 
 require (
+	github.com/andybalholm/brotli v1.1.1
 	github.com/go4x/got v1.0.0
 	github.com/google/uuid v1.6.0
+	github.com/klauspost/compress v1.18.0
 	github.com/sony/sonyflake v1.3.0
 	github.com/stretchr/testify v1.11.1
 	golang.org/x/crypto v0.45.0
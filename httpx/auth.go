@@ -0,0 +1,331 @@
+package httpx
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Authenticator adds credentials to outgoing requests.
+type Authenticator interface {
+	// Apply adds credentials to req, typically by setting headers.
+	Apply(req *http.Request) error
+}
+
+// Refresher is implemented by authenticators whose credentials can be renewed.
+// AuthTransport calls Refresh when a request is rejected with 401 Unauthorized
+// and replays the request once if it returns true.
+type Refresher interface {
+	// Refresh renews the credentials that were applied to the rejected request.
+	// Implementations must tolerate concurrent calls for the same rejected
+	// credentials and renew them only once.
+	Refresh(rejected *http.Request) (bool, error)
+}
+
+// AuthTransport is an http.RoundTripper that authenticates every request with Auth.
+// When the server answers 401 and Auth implements Refresher, the credentials are
+// refreshed and the request is replayed exactly once.
+type AuthTransport struct {
+	// Auth adds credentials to requests.
+	Auth Authenticator
+	// Transport sends the authenticated requests, http.DefaultTransport if nil.
+	Transport http.RoundTripper
+}
+
+// NewAuthTransport creates an AuthTransport sending requests with next,
+// http.DefaultTransport if nil.
+func NewAuthTransport(auth Authenticator, next http.RoundTripper) *AuthTransport {
+	return &AuthTransport{Auth: auth, Transport: next}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *AuthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport := t.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	authReq, err := t.authorize(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := transport.RoundTrip(authReq)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	refresher, ok := t.Auth.(Refresher)
+	if !ok || (req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) {
+		return resp, nil
+	}
+	replay, err := refresher.Refresh(authReq)
+	if err != nil {
+		_ = resp.Body.Close()
+		return nil, err
+	}
+	if !replay {
+		return resp, nil
+	}
+	_ = resp.Body.Close()
+
+	retryReq, err := t.authorize(req)
+	if err != nil {
+		return nil, err
+	}
+	return transport.RoundTrip(retryReq)
+}
+
+// authorize returns a copy of req with a fresh body and credentials applied,
+// since a RoundTripper must not modify the caller's request.
+func (t *AuthTransport) authorize(req *http.Request) (*http.Request, error) {
+	clone := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		clone.Body = body
+	}
+	if err := t.Auth.Apply(clone); err != nil {
+		return nil, err
+	}
+	return clone, nil
+}
+
+// WithAuth returns a copy of the client whose requests are authenticated by auth.
+// The original client is not modified.
+//
+// Example:
+//
+//	client := httpx.NewRestClient(nil).WithAuth(&httpx.ClientCredentials{
+//		TokenURL:     "https://auth.example.com/oauth/token",
+//		ClientID:     "id",
+//		ClientSecret: "secret",
+//	})
+//	resp, err := client.Get("https://api.example.com/users", nil)
+func (c *RestClient) WithAuth(auth Authenticator) *RestClient {
+	return c.withTransport(func(next http.RoundTripper) http.RoundTripper {
+		return NewAuthTransport(auth, next)
+	})
+}
+
+// withTransport returns a copy of the client whose transport is wrapped by wrap.
+func (c *RestClient) withTransport(wrap func(http.RoundTripper) http.RoundTripper) *RestClient {
+	client := *c.Client
+	client.Transport = wrap(c.Client.Transport)
+	return &RestClient{Client: &client}
+}
+
+// BasicAuth authenticates requests with HTTP Basic credentials.
+type BasicAuth struct {
+	Username string
+	Password string
+}
+
+// Apply implements Authenticator.
+func (a *BasicAuth) Apply(req *http.Request) error {
+	req.SetBasicAuth(a.Username, a.Password)
+	return nil
+}
+
+// BearerAuth authenticates requests with a static bearer token.
+type BearerAuth struct {
+	Token string
+}
+
+// Apply implements Authenticator.
+func (a *BearerAuth) Apply(req *http.Request) error {
+	req.Header.Set("Authorization", "Bearer "+a.Token)
+	return nil
+}
+
+// ClientCredentials authenticates requests with OAuth2 access tokens obtained
+// through the client credentials grant (RFC 6749 section 4.4).
+//
+// Tokens are cached and renewed shortly before they expire. When a request is
+// rejected with 401, the token is fetched again once, however many concurrent
+// requests were rejected with it.
+type ClientCredentials struct {
+	// TokenURL is the token endpoint.
+	TokenURL string
+	// ClientID and ClientSecret are sent with HTTP Basic authentication.
+	ClientID     string
+	ClientSecret string
+	// Scopes are the requested scopes, if any.
+	Scopes []string
+	// Params are extra form parameters sent to the token endpoint, e.g. audience.
+	Params url.Values
+	// Client is used to call the token endpoint, a client with a 30-second timeout if nil.
+	Client *http.Client
+	// RefreshBefore renews tokens this long before they expire, 30 seconds if zero.
+	RefreshBefore time.Duration
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// tokenResponse is the successful answer of an OAuth2 token endpoint.
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// Apply implements Authenticator.
+func (a *ClientCredentials) Apply(req *http.Request) error {
+	token, err := a.Token(req.Context())
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// Refresh implements Refresher. The token is fetched again only if the rejected
+// request carried the current token.
+func (a *ClientCredentials) Refresh(rejected *http.Request) (bool, error) {
+	used := strings.TrimPrefix(rejected.Header.Get("Authorization"), "Bearer ")
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if used != a.token {
+		return true, nil
+	}
+	return true, a.fetch(rejected.Context())
+}
+
+// Token returns a valid access token, fetching a new one if the cached token is
+// missing or about to expire.
+func (a *ClientCredentials) Token(ctx context.Context) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	refreshBefore := a.RefreshBefore
+	if refreshBefore == 0 {
+		refreshBefore = 30 * time.Second
+	}
+	if a.token != "" && (a.expires.IsZero() || time.Now().Add(refreshBefore).Before(a.expires)) {
+		return a.token, nil
+	}
+	if err := a.fetch(ctx); err != nil {
+		return "", err
+	}
+	return a.token, nil
+}
+
+// fetch requests a new token from the token endpoint. a.mu must be held.
+func (a *ClientCredentials) fetch(ctx context.Context) error {
+	form := url.Values{}
+	for k, v := range a.Params {
+		form[k] = v
+	}
+	form.Set("grant_type", "client_credentials")
+	if len(a.Scopes) > 0 {
+		form.Set("scope", strings.Join(a.Scopes, " "))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", ContentTypeApplicationFormUrlencoded)
+	req.Header.Set("Accept", ContentTypeApplicationJson)
+	req.SetBasicAuth(url.QueryEscape(a.ClientID), url.QueryEscape(a.ClientSecret))
+
+	client := a.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("httpx: token request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("httpx: token request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("httpx: token request failed: %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+
+	var tr tokenResponse
+	if err := json.Unmarshal(body, &tr); err != nil {
+		return fmt.Errorf("httpx: invalid token response: %w", err)
+	}
+	if tr.AccessToken == "" {
+		return fmt.Errorf("httpx: token response has no access_token")
+	}
+	a.token = tr.AccessToken
+	a.expires = time.Time{}
+	if tr.ExpiresIn > 0 {
+		a.expires = time.Now().Add(time.Duration(tr.ExpiresIn) * time.Second)
+	}
+	return nil
+}
+
+// HMACAuth signs requests with HMAC-SHA256.
+//
+// The signed string is the method, the request URI (path and query), the Unix
+// timestamp and the hex SHA-256 of the body, joined by newlines:
+//
+//	POST\n/v1/orders?dry_run=1\n1700000000\ne3b0c442...
+//
+// The timestamp and body hash are sent in the X-Timestamp and X-Content-SHA256
+// headers, and the signature in the Authorization header:
+//
+//	Authorization: HMAC-SHA256 KeyId=<KeyID>, Signature=<base64 signature>
+type HMACAuth struct {
+	// KeyID identifies the secret to the server.
+	KeyID string
+	// Secret is the shared signing key.
+	Secret []byte
+
+	// now returns the signing time; it is replaced in tests.
+	now func() time.Time
+}
+
+// Apply implements Authenticator.
+func (a *HMACAuth) Apply(req *http.Request) error {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	now := time.Now
+	if a.now != nil {
+		now = a.now
+	}
+	timestamp := strconv.FormatInt(now().Unix(), 10)
+	bodyHash := sha256.Sum256(body)
+	req.Header.Set("X-Timestamp", timestamp)
+	req.Header.Set("X-Content-SHA256", hex.EncodeToString(bodyHash[:]))
+	req.Header.Set("Authorization", fmt.Sprintf("HMAC-SHA256 KeyId=%s, Signature=%s",
+		a.KeyID, a.Sign(req.Method, req.URL.RequestURI(), timestamp, body)))
+	return nil
+}
+
+// Sign returns the base64 HMAC-SHA256 signature of a request with the given
+// method, request URI, timestamp and body. Servers use it to verify requests.
+func (a *HMACAuth) Sign(method, requestURI, timestamp string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, a.Secret)
+	mac.Write([]byte(strings.Join([]string{method, requestURI, timestamp, hex.EncodeToString(bodyHash[:])}, "\n")))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
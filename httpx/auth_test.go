@@ -0,0 +1,290 @@
+package httpx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRestClient_WithAuth_Static(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Header.Get("Authorization"))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name string
+		auth Authenticator
+		want string
+	}{
+		{"basic", &BasicAuth{Username: "user", Password: "pass"}, "Basic dXNlcjpwYXNz"},
+		{"bearer", &BearerAuth{Token: "abc"}, "Bearer abc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := NewRestClient(nil)
+			client := base.WithAuth(tt.auth)
+			resp, err := client.Get(server.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, _ := resp.String(); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+			if base.Client.Transport != DefaultClient.Client.Transport {
+				t.Error("WithAuth should not modify the original client")
+			}
+		})
+	}
+}
+
+func TestRequestOptions_Auth(t *testing.T) {
+	req := MustNewRequest(http.MethodGet, "https://example.com", nil, WithBearerToken("abc"))
+	if got := req.GetHeader().Get("Authorization"); got != "Bearer abc" {
+		t.Errorf("Expected bearer token, got %q", got)
+	}
+	req = MustNewRequest(http.MethodGet, "https://example.com", nil, WithBasicAuth("user", "pass"))
+	if user, pass, ok := req.GetRequest().BasicAuth(); !ok || user != "user" || pass != "pass" {
+		t.Errorf("Expected basic credentials, got %q %q %v", user, pass, ok)
+	}
+}
+
+// tokenServer issues numbered tokens and accepts only the latest one.
+type tokenServer struct {
+	issued  int32
+	current atomic.Value
+}
+
+func (s *tokenServer) start(t *testing.T, expiresIn int) (tokenURL, apiURL string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+		if user != "id" || pass != "secret" || r.FormValue("grant_type") != "client_credentials" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		token := fmt.Sprintf("token-%d", atomic.AddInt32(&s.issued, 1))
+		s.current.Store(token)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"access_token": token, "token_type": "Bearer", "expires_in": expiresIn,
+		})
+	})
+	mux.HandleFunc("/api", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get("Authorization") != "Bearer "+s.current.Load().(string) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write(body)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server.URL + "/token", server.URL + "/api"
+}
+
+func TestClientCredentials_CachesToken(t *testing.T) {
+	ts := &tokenServer{}
+	tokenURL, apiURL := ts.start(t, 3600)
+	client := NewRestClient(nil).WithAuth(&ClientCredentials{
+		TokenURL: tokenURL, ClientID: "id", ClientSecret: "secret", Scopes: []string{"read"},
+	})
+
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(apiURL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Close()
+		if !resp.IsSuccess() {
+			t.Fatalf("Expected success, got %d", resp.StatusCode)
+		}
+	}
+	if ts.issued != 1 {
+		t.Errorf("Expected the token to be cached, issued %d", ts.issued)
+	}
+}
+
+func TestClientCredentials_RefreshBeforeExpiry(t *testing.T) {
+	ts := &tokenServer{}
+	tokenURL, _ := ts.start(t, 10)
+	auth := &ClientCredentials{TokenURL: tokenURL, ClientID: "id", ClientSecret: "secret"}
+
+	for i := 0; i < 2; i++ {
+		if _, err := auth.Token(t.Context()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if ts.issued != 2 {
+		t.Errorf("Tokens expiring within RefreshBefore should be renewed, issued %d", ts.issued)
+	}
+
+	auth.RefreshBefore = time.Second
+	for i := 0; i < 2; i++ {
+		if _, err := auth.Token(t.Context()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if ts.issued != 2 {
+		t.Errorf("Expected a cached token, issued %d", ts.issued)
+	}
+}
+
+func TestClientCredentials_RefreshOnceOn401(t *testing.T) {
+	ts := &tokenServer{}
+	tokenURL, apiURL := ts.start(t, 3600)
+	auth := &ClientCredentials{TokenURL: tokenURL, ClientID: "id", ClientSecret: "secret"}
+	client := NewRestClient(nil).WithAuth(auth)
+	if _, err := auth.Token(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	// The server revokes the cached token.
+	ts.current.Store("revoked")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			body := fmt.Sprintf("payload-%d", i)
+			resp, err := client.Post(apiURL, strings.NewReader(body))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			got, _ := resp.String()
+			if resp.StatusCode != http.StatusOK || got != body {
+				t.Errorf("Expected replayed %q, got %d %q", body, resp.StatusCode, got)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if ts.issued != 2 {
+		t.Errorf("Concurrent 401s should refresh the token once, issued %d", ts.issued)
+	}
+}
+
+func TestAuthTransport_ReplaysOnlyOnce(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	var tokenHits int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&tokenHits, 1)
+		_, _ = fmt.Fprintf(w, `{"access_token":"t%d","expires_in":3600}`, n)
+	}))
+	defer tokenServer.Close()
+
+	client := NewRestClient(nil).WithAuth(&ClientCredentials{TokenURL: tokenServer.URL})
+	resp, err := client.Get(server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401 after the replay, got %d", resp.StatusCode)
+	}
+	if hits != 2 || tokenHits != 2 {
+		t.Errorf("Expected one replay and one refresh, got %d requests and %d tokens", hits, tokenHits)
+	}
+}
+
+type failingRefresher struct{}
+
+func (failingRefresher) Apply(req *http.Request) error {
+	req.Header.Set("Authorization", "Bearer expired")
+	return nil
+}
+
+func (failingRefresher) Refresh(*http.Request) (bool, error) {
+	return false, errors.New("refresh token revoked")
+}
+
+type closeRecorder struct {
+	io.Reader
+	closed atomic.Bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed.Store(true)
+	return nil
+}
+
+type unauthorizedTransport struct {
+	body *closeRecorder
+}
+
+func (t unauthorizedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: http.StatusUnauthorized, Header: http.Header{}, Body: t.body, Request: req}, nil
+}
+
+func TestAuthTransport_RefreshError(t *testing.T) {
+	body := &closeRecorder{Reader: strings.NewReader("unauthorized")}
+	transport := NewAuthTransport(failingRefresher{}, unauthorizedTransport{body: body})
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/", nil)
+	resp, err := transport.RoundTrip(req)
+	if err == nil || !strings.Contains(err.Error(), "refresh token revoked") {
+		t.Errorf("Expected the refresh error, got %v", err)
+	}
+	if resp != nil {
+		t.Error("A RoundTripper must not return a response with an error")
+	}
+	if !body.closed.Load() {
+		t.Error("The body of the rejected response should be closed")
+	}
+}
+
+func TestClientCredentials_TokenError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client := NewRestClient(nil).WithAuth(&ClientCredentials{TokenURL: server.URL})
+	if _, err := client.Get(server.URL, nil); err == nil || !strings.Contains(err.Error(), "invalid_client") {
+		t.Errorf("Expected the token endpoint error, got %v", err)
+	}
+}
+
+func TestHMACAuth(t *testing.T) {
+	auth := &HMACAuth{KeyID: "key-1", Secret: []byte("shh"), now: func() time.Time { return time.Unix(1700000000, 0) }}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		want := "HMAC-SHA256 KeyId=key-1, Signature=" + auth.Sign(r.Method, r.URL.RequestURI(), r.Header.Get("X-Timestamp"), body)
+		if r.Header.Get("Authorization") != want || r.Header.Get("X-Timestamp") != "1700000000" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+
+	client := NewRestClient(nil).WithAuth(auth)
+	resp, err := client.Post(server.URL+"/orders?dry_run=1", strings.NewReader("order"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body, _ := resp.String(); resp.StatusCode != http.StatusOK || body != "order" {
+		t.Errorf("Expected verified request, got %d %q", resp.StatusCode, body)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "https://example.com/", nil)
+	if err := auth.Apply(req); err != nil {
+		t.Fatal(err)
+	}
+	if got := req.Header.Get("X-Content-SHA256"); got != "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855" {
+		t.Errorf("Expected the SHA-256 of an empty body, got %s", got)
+	}
+}
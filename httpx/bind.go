@@ -0,0 +1,284 @@
+package httpx
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go4x/goal/errorx"
+)
+
+// MaxBindBodySize is the largest request body Bind and BindJSON accept, 10 MiB.
+const MaxBindBodySize = 10 << 20
+
+// Validator is implemented by request types that check themselves once bound.
+// Errors that do not wrap an errorx.PreferredError are reported as 400 Bad
+// Request.
+type Validator interface {
+	Validate() error
+}
+
+// Bind decodes r into the struct pointed to by v and validates it.
+//
+// Query parameters are bound first, to fields tagged `query:"name"`. The body is
+// then decoded according to its Content-Type: JSON with encoding/json, rejecting
+// unknown fields, and urlencoded or multipart forms into fields tagged
+// `form:"name"`. Requests without a body are accepted. Finally v.Validate is
+// called if v implements Validator.
+//
+// Errors are errorx.PreferredErrors carrying 400 Bad Request, 413 Content Too Large
+// or 415 Unsupported Media Type, ready for WriteError.
+func Bind(r *http.Request, v any) error {
+	if err := bindValues(r.URL.Query(), v, "query"); err != nil {
+		return err
+	}
+	if r.Body != nil && r.Body != http.NoBody && r.ContentLength != 0 {
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		switch {
+		case mediaType == ContentTypeApplicationJson || strings.HasSuffix(mediaType, "+json"):
+			if err := decodeJSONBody(r, v); err != nil {
+				return err
+			}
+		case mediaType == ContentTypeApplicationFormUrlencoded || mediaType == ContentTypeMultipartFormData:
+			if err := parseForm(r); err != nil {
+				return err
+			}
+			if err := bindValues(r.PostForm, v, "form"); err != nil {
+				return err
+			}
+		default:
+			return errorx.Prefer415("unsupported content type %q", r.Header.Get("Content-Type"))
+		}
+	}
+	return validate(v)
+}
+
+// BindJSON decodes the JSON body of r into v and validates it. Unknown fields
+// are rejected.
+func BindJSON(r *http.Request, v any) error {
+	if err := decodeJSONBody(r, v); err != nil {
+		return err
+	}
+	return validate(v)
+}
+
+// BindForm binds the urlencoded or multipart form of r, body and query, into the
+// fields of v tagged `form:"name"` and validates it.
+func BindForm(r *http.Request, v any) error {
+	if err := parseForm(r); err != nil {
+		return err
+	}
+	if err := bindValues(r.Form, v, "form"); err != nil {
+		return err
+	}
+	return validate(v)
+}
+
+// BindQuery binds the query parameters of r into the fields of v tagged
+// `query:"name"` and validates it.
+func BindQuery(r *http.Request, v any) error {
+	if err := bindValues(r.URL.Query(), v, "query"); err != nil {
+		return err
+	}
+	return validate(v)
+}
+
+func decodeJSONBody(r *http.Request, v any) error {
+	if r.Body == nil || r.Body == http.NoBody {
+		return errorx.Prefer400("request body is empty")
+	}
+	dec := json.NewDecoder(http.MaxBytesReader(nil, r.Body, MaxBindBodySize))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(err, &tooLarge):
+			return errorx.NewPreferredCodeErrf(http.StatusRequestEntityTooLarge, "request body exceeds %d bytes", tooLarge.Limit)
+		case errors.Is(err, io.EOF):
+			return errorx.Prefer400("request body is empty")
+		default:
+			return errorx.Prefer400("invalid JSON body: %v", err)
+		}
+	}
+	if dec.More() {
+		return errorx.Prefer400("invalid JSON body: unexpected data after the top-level value")
+	}
+	return nil
+}
+
+func parseForm(r *http.Request) error {
+	var err error
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == ContentTypeMultipartFormData {
+		err = r.ParseMultipartForm(MaxBindBodySize)
+	} else {
+		err = r.ParseForm()
+	}
+	if err != nil {
+		return errorx.Prefer400("invalid form: %v", err)
+	}
+	return nil
+}
+
+func validate(v any) error {
+	validator, ok := v.(Validator)
+	if !ok {
+		return nil
+	}
+	err := validator.Validate()
+	var preferred *errorx.PreferredError
+	if err == nil || errors.As(err, &preferred) {
+		return err
+	}
+	return errorx.NewPreferredErrCode(err, http.StatusBadRequest)
+}
+
+var (
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+	durationType        = reflect.TypeFor[time.Duration]()
+)
+
+// bindValues sets the fields of the struct pointed to by v tagged tag from
+// values. Fields without the tag are left alone.
+func bindValues(values url.Values, v any, tag string) error {
+	if len(values) == 0 {
+		return nil
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("httpx: bind target must be a non-nil struct pointer, got %T", v)
+	}
+	return bindStruct(values, rv.Elem(), tag)
+}
+
+func bindStruct(values url.Values, rv reflect.Value, tag string) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		// Like encoding/json, fields of embedded structs are promoted even if
+		// the embedded type itself is unexported.
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && field.Tag.Get(tag) == "" {
+			if err := bindStruct(values, rv.Field(i), tag); err != nil {
+				return err
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		name := fieldName(field, tag)
+		if name == "" {
+			continue
+		}
+		raw, ok := values[name]
+		if !ok || len(raw) == 0 {
+			continue
+		}
+		if err := setField(rv.Field(i), raw); err != nil {
+			return errorx.Prefer400("invalid %s parameter %q: %v", tag, name, err)
+		}
+	}
+	return nil
+}
+
+// fieldName returns the parameter name of field, or "" if it is skipped. Only
+// fields carrying the tag are bound, so that a client cannot set fields meant
+// for the JSON body, or not meant for input at all, through the URL or a form.
+func fieldName(field reflect.StructField, tag string) string {
+	value, ok := field.Tag.Lookup(tag)
+	if !ok {
+		return ""
+	}
+	name, _, _ := strings.Cut(value, ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return field.Name
+	}
+	return name
+}
+
+// setField parses raw into field. Slices take every value, other kinds the first.
+// Types implementing encoding.TextUnmarshaler, such as time.Time, decode themselves.
+func setField(field reflect.Value, raw []string) error {
+	if field.Kind() == reflect.Slice && !field.Type().Implements(textUnmarshalerType) &&
+		field.Type().Elem().Kind() != reflect.Uint8 {
+		slice := reflect.MakeSlice(field.Type(), len(raw), len(raw))
+		for i, s := range raw {
+			if err := setScalar(slice.Index(i), s); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+		return nil
+	}
+	return setScalar(field, raw[0])
+}
+
+func setScalar(field reflect.Value, s string) error {
+	if field.Kind() == reflect.Pointer {
+		ptr := reflect.New(field.Type().Elem())
+		if err := setScalar(ptr.Elem(), s); err != nil {
+			return err
+		}
+		field.Set(ptr)
+		return nil
+	}
+	if field.CanAddr() && field.Addr().Type().Implements(textUnmarshalerType) {
+		return field.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		// []byte takes the raw value.
+		field.SetBytes([]byte(s))
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
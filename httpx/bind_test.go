@@ -0,0 +1,177 @@
+package httpx
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go4x/goal/errorx"
+)
+
+type paging struct {
+	Page  int `query:"page" form:"page"`
+	Limit int `query:"limit" form:"limit"`
+}
+
+type createUser struct {
+	paging
+	Name    string        `json:"name" form:"name"`
+	Age     int           `json:"age" form:"age"`
+	Tags    []string      `json:"tags" form:"tag"`
+	Admin   *bool         `json:"admin" form:"admin"`
+	Timeout time.Duration `json:"-" query:"timeout" form:"timeout"`
+	Since   time.Time     `json:"since" query:"since"`
+	Ignored string        `json:"-" form:"-" query:"-"`
+}
+
+func (u *createUser) Validate() error {
+	if u.Name == "" {
+		return errors.New("name is required")
+	}
+	if u.Age < 0 {
+		return errorx.NewPreferredCodeErrf(http.StatusUnprocessableEntity, "age must not be negative")
+	}
+	return nil
+}
+
+func TestBind_JSON(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/users?page=2&timeout=5s&Ignored=x",
+		strings.NewReader(`{"name":"John","age":30,"tags":["a","b"],"admin":true}`))
+	r.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	var u createUser
+	if err := Bind(r, &u); err != nil {
+		t.Fatal(err)
+	}
+	if u.Name != "John" || u.Age != 30 || len(u.Tags) != 2 || u.Admin == nil || !*u.Admin ||
+		u.Page != 2 || u.Timeout != 5*time.Second || u.Ignored != "" {
+		t.Errorf("Unexpected binding %+v", u)
+	}
+}
+
+func TestBind_Form(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/users?since=2024-01-02T03:04:05Z",
+		strings.NewReader("name=Jane&age=25&tag=x&tag=y&admin=false&limit=10"))
+	r.Header.Set("Content-Type", ContentTypeApplicationFormUrlencoded)
+
+	var u createUser
+	if err := Bind(r, &u); err != nil {
+		t.Fatal(err)
+	}
+	if u.Name != "Jane" || u.Age != 25 || strings.Join(u.Tags, ",") != "x,y" || u.Admin == nil || *u.Admin ||
+		u.Limit != 10 || u.Since.Year() != 2024 {
+		t.Errorf("Unexpected binding %+v", u)
+	}
+}
+
+func TestBindForm_Multipart(t *testing.T) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	_ = mw.WriteField("name", "Ann")
+	_ = mw.WriteField("tag", "z")
+	_ = mw.Close()
+	r := httptest.NewRequest(http.MethodPost, "/users?age=41", &body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+
+	var u createUser
+	if err := BindForm(r, &u); err != nil {
+		t.Fatal(err)
+	}
+	if u.Name != "Ann" || u.Age != 41 || strings.Join(u.Tags, ",") != "z" {
+		t.Errorf("Unexpected binding %+v", u)
+	}
+}
+
+func TestBindQuery(t *testing.T) {
+	var p paging
+	if err := BindQuery(httptest.NewRequest(http.MethodGet, "/?page=3&limit=50", nil), &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Page != 3 || p.Limit != 50 {
+		t.Errorf("Unexpected binding %+v", p)
+	}
+}
+
+type signup struct {
+	Name    string `json:"name"`
+	IsAdmin bool   `json:"is_admin"`
+	Role    string
+	Ref     string `query:",omitempty"`
+}
+
+func TestBind_OnlyTaggedFields(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/users?is_admin=true&IsAdmin=true&Role=root&name=x&Ref=ad",
+		strings.NewReader(`{"name":"John"}`))
+	r.Header.Set("Content-Type", ContentTypeApplicationJson)
+
+	var u signup
+	if err := Bind(r, &u); err != nil {
+		t.Fatal(err)
+	}
+	if u.IsAdmin || u.Role != "" || u.Name != "John" {
+		t.Errorf("Fields without a query tag must not be bound from the query: %+v", u)
+	}
+	if u.Ref != "ad" {
+		t.Errorf("A query tag without a name should bind the field name, got %q", u.Ref)
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/users", strings.NewReader("name=John&is_admin=true"))
+	r.Header.Set("Content-Type", ContentTypeApplicationFormUrlencoded)
+	u = signup{}
+	if err := BindForm(r, &u); err != nil {
+		t.Fatal(err)
+	}
+	if u.IsAdmin || u.Name != "" {
+		t.Errorf("Fields without a form tag must not be bound from the form: %+v", u)
+	}
+}
+
+type wrappedValidation struct{}
+
+func (wrappedValidation) Validate() error {
+	return fmt.Errorf("checking quota: %w", errorx.NewPreferredCodeErrf(http.StatusTooManyRequests, "quota exceeded"))
+}
+
+func TestBind_WrappedPreferredValidation(t *testing.T) {
+	err := BindQuery(httptest.NewRequest(http.MethodGet, "/", nil), &wrappedValidation{})
+	if got := StatusOf(err); got != http.StatusTooManyRequests {
+		t.Errorf("Expected status %d, got %d (%v)", http.StatusTooManyRequests, got, err)
+	}
+}
+
+func TestBind_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		target      string
+		contentType string
+		body        string
+		status      int
+	}{
+		{"validation", "/", ContentTypeApplicationJson, `{"age":1}`, http.StatusBadRequest},
+		{"preferred validation", "/", ContentTypeApplicationJson, `{"name":"a","age":-1}`, http.StatusUnprocessableEntity},
+		{"malformed JSON", "/", ContentTypeApplicationJson, `{"name":`, http.StatusBadRequest},
+		{"unknown field", "/", ContentTypeApplicationJson, `{"name":"a","role":"root"}`, http.StatusBadRequest},
+		{"trailing data", "/", ContentTypeApplicationJson, `{"name":"a"} {}`, http.StatusBadRequest},
+		{"bad query", "/?page=two", ContentTypeApplicationJson, `{"name":"a"}`, http.StatusBadRequest},
+		{"bad form", "/", ContentTypeApplicationFormUrlencoded, "name=a&age=old", http.StatusBadRequest},
+		{"media type", "/", ContentTypeTextXml, "<user/>", http.StatusUnsupportedMediaType},
+		{"too large", "/", ContentTypeApplicationJson, `{"name":"` + strings.Repeat("a", MaxBindBodySize) + `"}`, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", tt.contentType)
+			var u createUser
+			err := Bind(r, &u)
+			if got := StatusOf(err); got != tt.status {
+				t.Errorf("Expected status %d, got %d (%v)", tt.status, got, err)
+			}
+		})
+	}
+}
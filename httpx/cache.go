@@ -0,0 +1,569 @@
+package httpx
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go4x/goal/ciphers/hash"
+	"github.com/go4x/goal/col/mapx"
+)
+
+const (
+	// HeaderFromCache is set to "1" on responses served by CacheTransport from storage.
+	HeaderFromCache = "X-From-Cache"
+
+	// cacheTimeHeader stores the time the response was received in the cached entry.
+	cacheTimeHeader = "X-Httpx-Cache-Time"
+	// cacheVaryPrefix prefixes the request header values selected by Vary in the cached entry.
+	cacheVaryPrefix = "X-Httpx-Cache-Vary-"
+	// cacheGenerationHeader stores the generation of the entries for a URL, so
+	// that variants stored before an invalidation are not served afterwards.
+	cacheGenerationHeader = "X-Httpx-Cache-Generation"
+)
+
+// cacheGenerations numbers the generations created by this process.
+var cacheGenerations atomic.Uint64
+
+// CacheStorage stores serialized HTTP responses for CacheTransport.
+// Implementations must be safe for concurrent use.
+type CacheStorage interface {
+	// Get returns the entry stored under key.
+	Get(key string) ([]byte, bool)
+	// Set stores an entry under key, replacing any previous one.
+	Set(key string, value []byte)
+	// Delete removes the entry stored under key.
+	Delete(key string)
+}
+
+// CacheTransport is an http.RoundTripper implementing a private HTTP cache as
+// described by RFC 9111.
+//
+// GET responses are stored when they carry explicit freshness (Cache-Control
+// max-age or Expires) or a validator (ETag or Last-Modified). Fresh responses are
+// served from storage; stale ones are revalidated with If-None-Match and
+// If-Modified-Since, and a 304 Not Modified answer refreshes the stored entry.
+// Requests carrying their own If-None-Match or If-Modified-Since are not
+// revalidated against storage, so the caller gets the server's 304 itself.
+// Vary is honoured: each variant is stored separately and only served to
+// requests with the same values of the selected headers. Successful unsafe
+// requests invalidate the stored entries for their URL.
+//
+// Example:
+//
+//	client := httpx.NewRestClient(&http.Client{
+//		Transport: httpx.NewCacheTransport(httpx.NewMemoryCache(1000), nil),
+//	})
+//	resp, err := client.Get("https://api.example.com/config", nil)
+//	fromCache := resp.HeaderValue(httpx.HeaderFromCache) == "1"
+type CacheTransport struct {
+	// Storage holds the cached responses.
+	Storage CacheStorage
+	// Transport performs the requests that cannot be answered from storage,
+	// http.DefaultTransport if nil.
+	Transport http.RoundTripper
+	// now returns the current time; it is replaced in tests.
+	now func() time.Time
+}
+
+// NewCacheTransport creates a CacheTransport storing responses in storage and
+// sending requests with next, http.DefaultTransport if nil.
+func NewCacheTransport(storage CacheStorage, next http.RoundTripper) *CacheTransport {
+	return &CacheTransport{Storage: storage, Transport: next}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *CacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := cacheKey(req)
+	if req.Method != http.MethodGet {
+		resp, err := t.transport().RoundTrip(req)
+		if err == nil && !isSafeMethod(req.Method) && resp.StatusCode < http.StatusBadRequest {
+			t.Storage.Delete(key)
+		}
+		return resp, err
+	}
+
+	reqCC := parseCacheControl(req.Header.Get("Cache-Control"))
+	if _, ok := reqCC["no-store"]; ok || req.Header.Get("Range") != "" {
+		return t.transport().RoundTrip(req)
+	}
+
+	cached, generation := t.load(req, key)
+	if cached != nil && t.isFresh(cached, reqCC) {
+		return t.serve(cached), nil
+	}
+	if cached == nil {
+		if _, ok := reqCC["only-if-cached"]; ok {
+			return &http.Response{
+				Status:     "504 Gateway Timeout",
+				StatusCode: http.StatusGatewayTimeout,
+				Proto:      "HTTP/1.1",
+				ProtoMajor: 1,
+				ProtoMinor: 1,
+				Header:     make(http.Header),
+				Body:       http.NoBody,
+				Request:    req,
+			}, nil
+		}
+	}
+
+	outReq := req
+	conditional := req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != ""
+	if cached != nil && !conditional {
+		etag, lastModified := cached.Header.Get("ETag"), cached.Header.Get("Last-Modified")
+		if etag != "" || lastModified != "" {
+			outReq = req.Clone(req.Context())
+			if etag != "" {
+				outReq.Header.Set("If-None-Match", etag)
+			}
+			if lastModified != "" {
+				outReq.Header.Set("If-Modified-Since", lastModified)
+			}
+		}
+	}
+
+	resp, err := t.transport().RoundTrip(outReq)
+	if err != nil {
+		if cached != nil {
+			_ = cached.Body.Close()
+		}
+		return nil, err
+	}
+
+	if cached != nil && resp.StatusCode == http.StatusNotModified && outReq != req {
+		_ = resp.Body.Close()
+		for name, values := range resp.Header {
+			if isEndToEndHeader(name) {
+				cached.Header[name] = values
+			}
+		}
+		cached.Header.Del("Age")
+		cached.Header.Set(cacheTimeHeader, strconv.FormatInt(t.clock().UnixNano(), 10))
+		body, err := io.ReadAll(cached.Body)
+		_ = cached.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		t.store(req, key, generation, cached, body)
+		cached.Body = io.NopCloser(bytes.NewReader(body))
+		return t.serve(cached), nil
+	}
+	if cached != nil {
+		_ = cached.Body.Close()
+	}
+
+	if !t.isStorable(req, resp) {
+		return resp, nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Header.Set(cacheTimeHeader, strconv.FormatInt(t.clock().UnixNano(), 10))
+	t.store(req, key, generation, resp, body)
+	resp.Header.Del(cacheTimeHeader)
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+// transport returns the underlying round tripper.
+func (t *CacheTransport) transport() http.RoundTripper {
+	if t.Transport != nil {
+		return t.Transport
+	}
+	return http.DefaultTransport
+}
+
+// clock returns the current time.
+func (t *CacheTransport) clock() time.Time {
+	if t.now != nil {
+		return t.now()
+	}
+	return time.Now()
+}
+
+// store serializes resp with body, together with the request headers selected
+// by Vary. The latest response for a URL is stored under key, and a response
+// with Vary also under the key of its variant, so that variants do not replace
+// each other. An empty generation starts a new one.
+func (t *CacheTransport) store(req *http.Request, key, generation string, resp *http.Response, body []byte) {
+	if generation == "" {
+		generation = fmt.Sprintf("%d-%d", time.Now().UnixNano(), cacheGenerations.Add(1))
+	}
+	stored := *resp
+	stored.Header = resp.Header.Clone()
+	stored.Header.Set(cacheGenerationHeader, generation)
+	names := varyHeaders(resp)
+	for _, name := range names {
+		stored.Header.Set(cacheVaryPrefix+name, varyValue(req, name))
+	}
+	stored.Body = io.NopCloser(bytes.NewReader(body))
+	stored.ContentLength = int64(len(body))
+	stored.TransferEncoding = nil
+	dump, err := httputil.DumpResponse(&stored, true)
+	if err != nil {
+		return
+	}
+	t.Storage.Set(key, dump)
+	if len(names) > 0 {
+		t.Storage.Set(variantKey(key, req, names), dump)
+	}
+}
+
+// load returns the stored response for req and the generation of the entries
+// for its URL. The response is nil if there is none matching the Vary headers
+// of req; the generation is empty if nothing is stored for the URL.
+func (t *CacheTransport) load(req *http.Request, key string) (*http.Response, string) {
+	resp := t.read(req, key)
+	if resp == nil {
+		return nil, ""
+	}
+	generation := resp.Header.Get(cacheGenerationHeader)
+	if names := varyHeaders(resp); !varyMatches(resp, req, names) {
+		_ = resp.Body.Close()
+		resp = t.read(req, variantKey(key, req, names))
+		if resp == nil {
+			return nil, generation
+		}
+		if resp.Header.Get(cacheGenerationHeader) != generation || !varyMatches(resp, req, varyHeaders(resp)) {
+			_ = resp.Body.Close()
+			return nil, generation
+		}
+	}
+	for name := range resp.Header {
+		if strings.HasPrefix(name, cacheVaryPrefix) {
+			resp.Header.Del(name)
+		}
+	}
+	resp.Header.Del(cacheGenerationHeader)
+	return resp, generation
+}
+
+// read returns the response stored under key, or nil if there is none.
+func (t *CacheTransport) read(req *http.Request, key string) *http.Response {
+	data, ok := t.Storage.Get(key)
+	if !ok {
+		return nil
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(data)), req)
+	if err != nil {
+		t.Storage.Delete(key)
+		return nil
+	}
+	return resp
+}
+
+// serve prepares a stored response for the caller: it sets Age and
+// HeaderFromCache and removes the internal bookkeeping headers.
+func (t *CacheTransport) serve(resp *http.Response) *http.Response {
+	if age := t.currentAge(resp); age >= 0 {
+		resp.Header.Set("Age", strconv.FormatInt(int64(age/time.Second), 10))
+	}
+	resp.Header.Del(cacheTimeHeader)
+	resp.Header.Set(HeaderFromCache, "1")
+	return resp
+}
+
+// isFresh reports whether the cached response can be served without revalidation.
+func (t *CacheTransport) isFresh(resp *http.Response, reqCC map[string]string) bool {
+	respCC := parseCacheControl(resp.Header.Get("Cache-Control"))
+	if _, ok := respCC["no-cache"]; ok {
+		return false
+	}
+	if _, ok := reqCC["no-cache"]; ok {
+		return false
+	}
+
+	age := t.currentAge(resp)
+	lifetime := freshnessLifetime(resp, respCC)
+	if v, ok := reqCC["max-age"]; ok {
+		if maxAge, err := strconv.Atoi(v); err == nil && age > time.Duration(maxAge)*time.Second {
+			return false
+		}
+	}
+	if v, ok := reqCC["min-fresh"]; ok {
+		if minFresh, err := strconv.Atoi(v); err == nil {
+			age += time.Duration(minFresh) * time.Second
+		}
+	}
+	if v, ok := reqCC["max-stale"]; ok {
+		if _, mustRevalidate := respCC["must-revalidate"]; !mustRevalidate {
+			if v == "" {
+				return true
+			}
+			if maxStale, err := strconv.Atoi(v); err == nil {
+				lifetime += time.Duration(maxStale) * time.Second
+			}
+		}
+	}
+	return lifetime > age
+}
+
+// currentAge computes the age of a stored response following RFC 9111 section 4.2.3.
+// It returns -1 if the entry carries no storage time.
+func (t *CacheTransport) currentAge(resp *http.Response) time.Duration {
+	nanos, err := strconv.ParseInt(resp.Header.Get(cacheTimeHeader), 10, 64)
+	if err != nil {
+		return -1
+	}
+	responseTime := time.Unix(0, nanos)
+
+	var apparentAge time.Duration
+	if date, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+		apparentAge = max(0, responseTime.Sub(date))
+	}
+	var ageValue time.Duration
+	if seconds, err := strconv.ParseInt(resp.Header.Get("Age"), 10, 64); err == nil && seconds > 0 {
+		ageValue = time.Duration(seconds) * time.Second
+	}
+	return max(apparentAge, ageValue) + t.clock().Sub(responseTime)
+}
+
+// isStorable reports whether resp may be stored for req.
+func (t *CacheTransport) isStorable(req *http.Request, resp *http.Response) bool {
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNonAuthoritativeInfo, http.StatusMultipleChoices,
+		http.StatusMovedPermanently, http.StatusNotFound, http.StatusGone:
+	default:
+		return false
+	}
+	respCC := parseCacheControl(resp.Header.Get("Cache-Control"))
+	if _, ok := respCC["no-store"]; ok {
+		return false
+	}
+	if _, ok := parseCacheControl(req.Header.Get("Cache-Control"))["no-store"]; ok {
+		return false
+	}
+	for _, name := range varyHeaders(resp) {
+		if name == "*" {
+			return false
+		}
+	}
+	if freshnessLifetime(resp, respCC) > 0 {
+		return true
+	}
+	return resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != ""
+}
+
+// freshnessLifetime returns how long resp is fresh after it was generated,
+// using max-age, Expires, or a heuristic based on Last-Modified.
+func freshnessLifetime(resp *http.Response, respCC map[string]string) time.Duration {
+	if v, ok := respCC["max-age"]; ok {
+		if seconds, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return time.Duration(seconds) * time.Second
+		}
+		return 0
+	}
+	date, dateErr := http.ParseTime(resp.Header.Get("Date"))
+	if expires := resp.Header.Get("Expires"); expires != "" {
+		exp, err := http.ParseTime(expires)
+		if err != nil || dateErr != nil {
+			return 0
+		}
+		return exp.Sub(date)
+	}
+	if lastModified, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil && dateErr == nil {
+		if _, ok := respCC["no-cache"]; !ok && date.After(lastModified) {
+			return date.Sub(lastModified) / 10
+		}
+	}
+	return 0
+}
+
+// parseCacheControl parses a Cache-Control header into directive names and values.
+// It returns nil if the header is empty.
+func parseCacheControl(header string) map[string]string {
+	if header == "" {
+		return nil
+	}
+	directives := make(map[string]string)
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(part, "=")
+		directives[strings.ToLower(strings.TrimSpace(name))] = strings.Trim(strings.TrimSpace(value), `"`)
+	}
+	return directives
+}
+
+// varyHeaders returns the canonical header names listed in the Vary header of resp.
+func varyHeaders(resp *http.Response) []string {
+	var names []string
+	for _, value := range resp.Header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	return names
+}
+
+// cacheKey returns the storage key for requests to the same resource.
+func cacheKey(req *http.Request) string {
+	return req.URL.String()
+}
+
+// variantKey returns the storage key for the variant of the resource at key
+// selected by the values of the Vary headers names in req.
+func variantKey(key string, req *http.Request, names []string) string {
+	var b strings.Builder
+	b.WriteString(key)
+	for _, name := range names {
+		fmt.Fprintf(&b, "\n%s: %s", name, varyValue(req, name))
+	}
+	return b.String()
+}
+
+// varyValue returns all the values of the request header name, as compared
+// for Vary.
+func varyValue(req *http.Request, name string) string {
+	return strings.Join(req.Header.Values(name), ", ")
+}
+
+// varyMatches reports whether the stored response resp was selected with the
+// same values of the Vary headers names as req.
+func varyMatches(resp *http.Response, req *http.Request, names []string) bool {
+	for _, name := range names {
+		if resp.Header.Get(cacheVaryPrefix+name) != varyValue(req, name) {
+			return false
+		}
+	}
+	return true
+}
+
+// isSafeMethod reports whether method is safe as defined by RFC 9110.
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// isEndToEndHeader reports whether a 304 response header should replace the
+// stored one.
+func isEndToEndHeader(name string) bool {
+	switch http.CanonicalHeaderKey(name) {
+	case "Connection", "Keep-Alive", "Proxy-Authenticate", "Proxy-Authorization",
+		"Te", "Trailer", "Transfer-Encoding", "Upgrade", "Content-Length":
+		return false
+	}
+	return true
+}
+
+// MemoryCache is an in-memory CacheStorage that evicts the least recently used
+// entry once it holds more than its capacity.
+type MemoryCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  *mapx.LinkedMap[string, []byte]
+}
+
+// NewMemoryCache creates a MemoryCache holding at most capacity entries.
+// A capacity of zero or less means unlimited.
+func NewMemoryCache(capacity int) *MemoryCache {
+	return &MemoryCache{capacity: capacity, entries: mapx.NewLinkedMap[string, []byte]()}
+}
+
+// Get returns the entry stored under key and marks it as recently used.
+func (c *MemoryCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, ok := c.entries.Get(key)
+	if ok {
+		c.entries.MoveToEnd(key)
+	}
+	return value, ok
+}
+
+// Set stores an entry under key, evicting the least recently used entry if needed.
+func (c *MemoryCache) Set(key string, value []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries.Put(key, value)
+	c.entries.MoveToEnd(key)
+	for c.capacity > 0 && c.entries.Size() > c.capacity {
+		oldest, _, _ := c.entries.First()
+		c.entries.Del(oldest)
+	}
+}
+
+// Delete removes the entry stored under key.
+func (c *MemoryCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries.Del(key)
+}
+
+// Len returns the number of stored entries.
+func (c *MemoryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.entries.Size()
+}
+
+// DiskCache is a CacheStorage keeping one file per entry in a directory.
+// File names are the SHA-256 of the key, and writes are atomic.
+type DiskCache struct {
+	dir string
+}
+
+// NewDiskCache creates a DiskCache in dir, creating the directory if needed.
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &DiskCache{dir: dir}, nil
+}
+
+// Get returns the entry stored under key.
+func (c *DiskCache) Get(key string) ([]byte, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// Set stores an entry under key. Write errors are ignored, since a missing
+// entry only causes a cache miss.
+func (c *DiskCache) Set(key string, value []byte) {
+	f, err := os.CreateTemp(c.dir, ".tmp-*")
+	if err != nil {
+		return
+	}
+	_, err = f.Write(value)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), c.path(key))
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+	}
+}
+
+// Delete removes the entry stored under key.
+func (c *DiskCache) Delete(key string) {
+	_ = os.Remove(c.path(key))
+}
+
+// path returns the file holding the entry for key.
+func (c *DiskCache) path(key string) string {
+	return filepath.Join(c.dir, hash.SHA256(key))
+}
@@ -0,0 +1,293 @@
+package httpx
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newCacheTestClient returns a client using a CacheTransport with a controllable clock.
+func newCacheTestClient(storage CacheStorage, now *time.Time) *RestClient {
+	transport := NewCacheTransport(storage, nil)
+	transport.now = func() time.Time { return *now }
+	return NewRestClient(&http.Client{Transport: transport})
+}
+
+func readCached(t *testing.T, client *RestClient, url string, header ...string) (string, bool) {
+	t.Helper()
+	var options []RequestOption
+	for i := 0; i+1 < len(header); i += 2 {
+		options = append(options, WithHeader(header[i], header[i+1]))
+	}
+	resp, err := client.send(http.MethodGet, url, nil, options...)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	body, err := resp.String()
+	if err != nil {
+		t.Fatalf("Failed to read body: %v", err)
+	}
+	return body, resp.HeaderValue(HeaderFromCache) == "1"
+}
+
+func TestCacheTransport_MaxAge(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		_, _ = io.WriteString(w, "payload")
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	client := newCacheTestClient(NewMemoryCache(10), &now)
+
+	if body, cached := readCached(t, client, server.URL); body != "payload" || cached {
+		t.Fatalf("First request: body=%q cached=%v", body, cached)
+	}
+	now = now.Add(30 * time.Second)
+	if body, cached := readCached(t, client, server.URL); body != "payload" || !cached {
+		t.Fatalf("Second request should be served from cache: body=%q cached=%v", body, cached)
+	}
+	now = now.Add(31 * time.Second)
+	if _, cached := readCached(t, client, server.URL); cached {
+		t.Fatal("Stale response without validators should be fetched again")
+	}
+	if hits != 2 {
+		t.Errorf("Expected 2 origin requests, got %d", hits)
+	}
+}
+
+func TestCacheTransport_Revalidate(t *testing.T) {
+	var hits, notModified int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("ETag", `"abc"`)
+		w.Header().Set("Cache-Control", "no-cache")
+		if r.Header.Get("If-None-Match") == `"abc"` {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = io.WriteString(w, "versioned")
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	client := newCacheTestClient(NewMemoryCache(10), &now)
+
+	readCached(t, client, server.URL)
+	body, cached := readCached(t, client, server.URL)
+	if body != "versioned" || !cached {
+		t.Fatalf("Revalidated response: body=%q cached=%v", body, cached)
+	}
+	if hits != 2 || notModified != 1 {
+		t.Errorf("Expected 2 origin requests with 1 revalidation, got %d and %d", hits, notModified)
+	}
+}
+
+func TestCacheTransport_Expires(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		now := time.Now().UTC()
+		w.Header().Set("Date", now.Format(http.TimeFormat))
+		w.Header().Set("Expires", now.Add(time.Hour).Format(http.TimeFormat))
+		_, _ = io.WriteString(w, "expires")
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	client := newCacheTestClient(NewMemoryCache(10), &now)
+	readCached(t, client, server.URL)
+	if _, cached := readCached(t, client, server.URL); !cached {
+		t.Error("Response should be fresh until Expires")
+	}
+	if hits != 1 {
+		t.Errorf("Expected 1 origin request, got %d", hits)
+	}
+}
+
+func TestCacheTransport_Vary(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Vary", "Accept-Language")
+		_, _ = io.WriteString(w, r.Header.Get("Accept-Language"))
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	client := newCacheTestClient(NewMemoryCache(10), &now)
+
+	readCached(t, client, server.URL, "Accept-Language", "en")
+	if body, cached := readCached(t, client, server.URL, "Accept-Language", "en"); body != "en" || !cached {
+		t.Errorf("Same Vary value should hit the cache: body=%q cached=%v", body, cached)
+	}
+	if body, cached := readCached(t, client, server.URL, "Accept-Language", "fr"); body != "fr" || cached {
+		t.Errorf("Different Vary value should miss the cache: body=%q cached=%v", body, cached)
+	}
+	if hits != 2 {
+		t.Errorf("Expected 2 origin requests, got %d", hits)
+	}
+}
+
+func TestCacheTransport_VaryVariants(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&hits, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Vary", "Accept-Language")
+		_, _ = fmt.Fprintf(w, "%s %d", r.Header.Get("Accept-Language"), n)
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	client := newCacheTestClient(NewMemoryCache(10), &now)
+
+	readCached(t, client, server.URL, "Accept-Language", "en")
+	readCached(t, client, server.URL, "Accept-Language", "fr")
+	if body, cached := readCached(t, client, server.URL, "Accept-Language", "en"); body != "en 1" || !cached {
+		t.Errorf("Variants should not replace each other: body=%q cached=%v", body, cached)
+	}
+	if body, cached := readCached(t, client, server.URL, "Accept-Language", "fr"); body != "fr 2" || !cached {
+		t.Errorf("Latest variant should hit the cache: body=%q cached=%v", body, cached)
+	}
+	if body, cached := readCached(t, client, server.URL); body != " 3" || cached {
+		t.Errorf("Missing Vary header should miss the cache: body=%q cached=%v", body, cached)
+	}
+
+	if _, err := client.Post(server.URL, nil); err != nil {
+		t.Fatal(err)
+	}
+	readCached(t, client, server.URL, "Accept-Language", "fr")
+	if body, cached := readCached(t, client, server.URL, "Accept-Language", "en"); body != "en 6" || cached {
+		t.Errorf("Variants stored before an invalidation must not be served: body=%q cached=%v", body, cached)
+	}
+}
+
+func TestCacheTransport_CallerConditional(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v2"`)
+		w.Header().Set("Cache-Control", "no-cache")
+		if r.Header.Get("If-None-Match") == `"v2"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = io.WriteString(w, "v2")
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	client := newCacheTestClient(NewMemoryCache(10), &now)
+	readCached(t, client, server.URL)
+
+	resp, err := client.send(http.MethodGet, server.URL, nil, WithHeader("If-None-Match", `"v2"`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Close()
+	if resp.StatusCode != http.StatusNotModified || resp.HeaderValue(HeaderFromCache) == "1" {
+		t.Errorf("The caller's conditional request should get the server's 304, got %d", resp.StatusCode)
+	}
+
+	resp, err = client.send(http.MethodGet, server.URL, nil, WithHeader("If-None-Match", `"v1"`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body, _ := resp.String(); resp.StatusCode != http.StatusOK || body != "v2" {
+		t.Errorf("A stale caller validator should get the full response, got %d %q", resp.StatusCode, body)
+	}
+}
+
+func TestCacheTransport_NoStoreAndInvalidation(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		if r.URL.Path == "/private" {
+			w.Header().Set("Cache-Control", "no-store")
+		} else {
+			w.Header().Set("Cache-Control", "max-age=60")
+		}
+		_, _ = io.WriteString(w, "data")
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	storage := NewMemoryCache(10)
+	client := newCacheTestClient(storage, &now)
+
+	readCached(t, client, server.URL+"/private")
+	if _, cached := readCached(t, client, server.URL+"/private"); cached {
+		t.Error("no-store response must not be cached")
+	}
+
+	readCached(t, client, server.URL+"/item")
+	if _, err := client.Delete(server.URL + "/item"); err != nil {
+		t.Fatal(err)
+	}
+	if _, cached := readCached(t, client, server.URL+"/item"); cached {
+		t.Error("DELETE should invalidate the cached entry")
+	}
+	if hits != 5 {
+		t.Errorf("Expected 5 origin requests, got %d", hits)
+	}
+}
+
+func TestMemoryCache_LRU(t *testing.T) {
+	cache := NewMemoryCache(2)
+	cache.Set("a", []byte("1"))
+	cache.Set("b", []byte("2"))
+	cache.Get("a")
+	cache.Set("c", []byte("3"))
+
+	if _, ok := cache.Get("b"); ok {
+		t.Error("Least recently used entry should be evicted")
+	}
+	if v, ok := cache.Get("a"); !ok || string(v) != "1" {
+		t.Error("Recently used entry should be kept")
+	}
+	if cache.Len() != 2 {
+		t.Errorf("Expected 2 entries, got %d", cache.Len())
+	}
+	cache.Delete("a")
+	if _, ok := cache.Get("a"); ok {
+		t.Error("Deleted entry should be gone")
+	}
+}
+
+func TestDiskCache(t *testing.T) {
+	cache, err := NewDiskCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.Set("https://example.com/a?b=c", []byte("entry"))
+	if v, ok := cache.Get("https://example.com/a?b=c"); !ok || string(v) != "entry" {
+		t.Errorf("Expected stored entry, got %q %v", v, ok)
+	}
+	cache.Delete("https://example.com/a?b=c")
+	if _, ok := cache.Get("https://example.com/a?b=c"); ok {
+		t.Error("Deleted entry should be gone")
+	}
+}
+
+func TestParseCacheControl(t *testing.T) {
+	cc := parseCacheControl(`max-age=60, No-Cache, private="Set-Cookie"`)
+	if cc["max-age"] != "60" {
+		t.Errorf("Expected max-age=60, got %q", cc["max-age"])
+	}
+	if _, ok := cc["no-cache"]; !ok {
+		t.Error("Expected no-cache directive")
+	}
+	if cc["private"] != "Set-Cookie" {
+		t.Errorf("Expected private=Set-Cookie, got %q", cc["private"])
+	}
+	if parseCacheControl("") != nil {
+		t.Error("Empty header should parse to nil")
+	}
+}
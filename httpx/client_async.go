@@ -16,8 +16,14 @@ type AsyncResult struct {
 
 // AsyncClient provides asynchronous HTTP request capabilities.
 // It wraps RestClient and provides methods that return channels for non-blocking operations.
+//
+// By default every call runs immediately in its own goroutine. Use
+// NewAsyncClientWithLimit to bound the number of requests in flight.
 type AsyncClient struct {
 	*RestClient
+
+	// sem holds one token per request in flight, nil for no limit.
+	sem chan struct{}
 }
 
 // NewAsyncClient creates a new AsyncClient with the given http.Client.
@@ -31,242 +37,140 @@ func NewAsyncClient(client *http.Client) *AsyncClient {
 
 // GetAsync sends an asynchronous GET request and returns a channel that will receive the result.
 func (ac *AsyncClient) GetAsync(url string, params url.Values) <-chan AsyncResult {
-	resultChan := make(chan AsyncResult, 1)
-
-	go func() {
-		resp, err := ac.Get(url, params)
-		resultChan <- AsyncResult{Resp: resp, Err: err}
-	}()
-
-	return resultChan
+	return ac.async(context.Background(), func() (*Response, error) {
+		return ac.Get(url, params)
+	})
 }
 
 // GetWithBodyAsync sends an asynchronous GET request with body and returns a channel that will receive the result.
 func (ac *AsyncClient) GetWithBodyAsync(url string, body io.Reader) <-chan AsyncResult {
-	resultChan := make(chan AsyncResult, 1)
-
-	go func() {
-		resp, err := ac.GetWithBody(url, body)
-		resultChan <- AsyncResult{Resp: resp, Err: err}
-	}()
-
-	return resultChan
+	return ac.async(context.Background(), func() (*Response, error) {
+		return ac.GetWithBody(url, body)
+	})
 }
 
 // GetJsonAsync sends an asynchronous GET request with JSON body and returns a channel that will receive the result.
 func (ac *AsyncClient) GetJsonAsync(url string, body io.Reader) <-chan AsyncResult {
-	resultChan := make(chan AsyncResult, 1)
-
-	go func() {
-		resp, err := ac.GetJson(url, body)
-		resultChan <- AsyncResult{Resp: resp, Err: err}
-	}()
-
-	return resultChan
+	return ac.async(context.Background(), func() (*Response, error) {
+		return ac.GetJson(url, body)
+	})
 }
 
 // GetFormAsync sends an asynchronous GET request with form data and returns a channel that will receive the result.
 func (ac *AsyncClient) GetFormAsync(url string, body io.Reader) <-chan AsyncResult {
-	resultChan := make(chan AsyncResult, 1)
-
-	go func() {
-		resp, err := ac.GetForm(url, body)
-		resultChan <- AsyncResult{Resp: resp, Err: err}
-	}()
-
-	return resultChan
+	return ac.async(context.Background(), func() (*Response, error) {
+		return ac.GetForm(url, body)
+	})
 }
 
 // PostAsync sends an asynchronous POST request and returns a channel that will receive the result.
 func (ac *AsyncClient) PostAsync(url string, body io.Reader) <-chan AsyncResult {
-	resultChan := make(chan AsyncResult, 1)
-
-	go func() {
-		resp, err := ac.Post(url, body)
-		resultChan <- AsyncResult{Resp: resp, Err: err}
-	}()
-
-	return resultChan
+	return ac.async(context.Background(), func() (*Response, error) {
+		return ac.Post(url, body)
+	})
 }
 
 // PostJsonAsync sends an asynchronous POST request with JSON content type and returns a channel that will receive the result.
 func (ac *AsyncClient) PostJsonAsync(url string, body io.Reader) <-chan AsyncResult {
-	resultChan := make(chan AsyncResult, 1)
-
-	go func() {
-		resp, err := ac.PostJson(url, body)
-		resultChan <- AsyncResult{Resp: resp, Err: err}
-	}()
-
-	return resultChan
+	return ac.async(context.Background(), func() (*Response, error) {
+		return ac.PostJson(url, body)
+	})
 }
 
 // PostFormAsync sends an asynchronous POST request with form data and returns a channel that will receive the result.
 func (ac *AsyncClient) PostFormAsync(url string, body io.Reader) <-chan AsyncResult {
-	resultChan := make(chan AsyncResult, 1)
-
-	go func() {
-		resp, err := ac.PostForm(url, body)
-		resultChan <- AsyncResult{Resp: resp, Err: err}
-	}()
-
-	return resultChan
+	return ac.async(context.Background(), func() (*Response, error) {
+		return ac.PostForm(url, body)
+	})
 }
 
 // PutAsync sends an asynchronous PUT request and returns a channel that will receive the result.
 func (ac *AsyncClient) PutAsync(url string, body io.Reader) <-chan AsyncResult {
-	resultChan := make(chan AsyncResult, 1)
-
-	go func() {
-		resp, err := ac.Put(url, body)
-		resultChan <- AsyncResult{Resp: resp, Err: err}
-	}()
-
-	return resultChan
+	return ac.async(context.Background(), func() (*Response, error) {
+		return ac.Put(url, body)
+	})
 }
 
 // PutJsonAsync sends an asynchronous PUT request with JSON content type and returns a channel that will receive the result.
 func (ac *AsyncClient) PutJsonAsync(url string, body io.Reader) <-chan AsyncResult {
-	resultChan := make(chan AsyncResult, 1)
-
-	go func() {
-		resp, err := ac.PutJson(url, body)
-		resultChan <- AsyncResult{Resp: resp, Err: err}
-	}()
-
-	return resultChan
+	return ac.async(context.Background(), func() (*Response, error) {
+		return ac.PutJson(url, body)
+	})
 }
 
 // PutFormAsync sends an asynchronous PUT request with form data and returns a channel that will receive the result.
 func (ac *AsyncClient) PutFormAsync(url string, body io.Reader) <-chan AsyncResult {
-	resultChan := make(chan AsyncResult, 1)
-
-	go func() {
-		resp, err := ac.PutForm(url, body)
-		resultChan <- AsyncResult{Resp: resp, Err: err}
-	}()
-
-	return resultChan
+	return ac.async(context.Background(), func() (*Response, error) {
+		return ac.PutForm(url, body)
+	})
 }
 
 // PatchAsync sends an asynchronous PATCH request and returns a channel that will receive the result.
 func (ac *AsyncClient) PatchAsync(url string, body io.Reader) <-chan AsyncResult {
-	resultChan := make(chan AsyncResult, 1)
-
-	go func() {
-		resp, err := ac.Patch(url, body)
-		resultChan <- AsyncResult{Resp: resp, Err: err}
-	}()
-
-	return resultChan
+	return ac.async(context.Background(), func() (*Response, error) {
+		return ac.Patch(url, body)
+	})
 }
 
 // PatchJsonAsync sends an asynchronous PATCH request with JSON content type and returns a channel that will receive the result.
 func (ac *AsyncClient) PatchJsonAsync(url string, body io.Reader) <-chan AsyncResult {
-	resultChan := make(chan AsyncResult, 1)
-
-	go func() {
-		resp, err := ac.PatchJson(url, body)
-		resultChan <- AsyncResult{Resp: resp, Err: err}
-	}()
-
-	return resultChan
+	return ac.async(context.Background(), func() (*Response, error) {
+		return ac.PatchJson(url, body)
+	})
 }
 
 // PatchFormAsync sends an asynchronous PATCH request with form data and returns a channel that will receive the result.
 func (ac *AsyncClient) PatchFormAsync(url string, body io.Reader) <-chan AsyncResult {
-	resultChan := make(chan AsyncResult, 1)
-
-	go func() {
-		resp, err := ac.PatchForm(url, body)
-		resultChan <- AsyncResult{Resp: resp, Err: err}
-	}()
-
-	return resultChan
+	return ac.async(context.Background(), func() (*Response, error) {
+		return ac.PatchForm(url, body)
+	})
 }
 
 // DeleteAsync sends an asynchronous DELETE request and returns a channel that will receive the result.
 func (ac *AsyncClient) DeleteAsync(url string) <-chan AsyncResult {
-	resultChan := make(chan AsyncResult, 1)
-
-	go func() {
-		resp, err := ac.Delete(url)
-		resultChan <- AsyncResult{Resp: resp, Err: err}
-	}()
-
-	return resultChan
+	return ac.async(context.Background(), func() (*Response, error) {
+		return ac.Delete(url)
+	})
 }
 
 // DeleteJsonAsync sends an asynchronous DELETE request with JSON body and returns a channel that will receive the result.
 func (ac *AsyncClient) DeleteJsonAsync(url string, body io.Reader) <-chan AsyncResult {
-	resultChan := make(chan AsyncResult, 1)
-
-	go func() {
-		resp, err := ac.DeleteJson(url, body)
-		resultChan <- AsyncResult{Resp: resp, Err: err}
-	}()
-
-	return resultChan
+	return ac.async(context.Background(), func() (*Response, error) {
+		return ac.DeleteJson(url, body)
+	})
 }
 
 // DeleteFormAsync sends an asynchronous DELETE request with form data and returns a channel that will receive the result.
 func (ac *AsyncClient) DeleteFormAsync(url string, body io.Reader) <-chan AsyncResult {
-	resultChan := make(chan AsyncResult, 1)
-
-	go func() {
-		resp, err := ac.DeleteForm(url, body)
-		resultChan <- AsyncResult{Resp: resp, Err: err}
-	}()
-
-	return resultChan
+	return ac.async(context.Background(), func() (*Response, error) {
+		return ac.DeleteForm(url, body)
+	})
 }
 
 // OptionsAsync sends an asynchronous OPTIONS request and returns a channel that will receive the result.
 func (ac *AsyncClient) OptionsAsync(url string, options ...RequestOption) <-chan AsyncResult {
-	resultChan := make(chan AsyncResult, 1)
-
-	go func() {
-		resp, err := ac.Options(url, options...)
-		resultChan <- AsyncResult{Resp: resp, Err: err}
-	}()
-
-	return resultChan
+	return ac.async(context.Background(), func() (*Response, error) {
+		return ac.Options(url, options...)
+	})
 }
 
 // BatchAsync sends multiple asynchronous requests and returns a channel that will receive all results.
 // The results will be in the same order as the requests.
+// At most the limit of the client, set with NewAsyncClientWithLimit, requests
+// are in flight at once, and all of them without a limit. Use BatchAsyncContext
+// with BatchOptions.Concurrency for a per-call limit, cancellation and
+// fail-fast batches.
 func (ac *AsyncClient) BatchAsync(requests []Request) <-chan []AsyncResult {
 	resultChan := make(chan []AsyncResult, 1)
 
 	go func() {
 		results := make([]AsyncResult, len(requests))
-
-		// Use goroutines for each request
-		type resultWithIndex struct {
-			index  int
-			result AsyncResult
+		stream := ac.stream(context.Background(), requests, BatchOptions{}, func(_ context.Context, req Request) (*Response, error) {
+			return ac.executeAsyncRequest(req)
+		})
+		for result := range stream {
+			results[result.Index] = result.AsyncResult
 		}
-
-		innerResultChan := make(chan resultWithIndex, len(requests))
-
-		// Start all requests concurrently
-		for i, req := range requests {
-			go func(i int, req Request) {
-				resp, err := ac.executeAsyncRequest(req)
-
-				innerResultChan <- resultWithIndex{
-					index:  i,
-					result: AsyncResult{Resp: resp, Err: err},
-				}
-			}(i, req)
-		}
-
-		// Collect all results
-		for i := 0; i < len(requests); i++ {
-			result := <-innerResultChan
-			results[result.index] = result.result
-		}
-
 		resultChan <- results
 	}()
 
@@ -378,28 +282,20 @@ func (ac *AsyncClient) deleteWithBody(url string, body io.Reader) (*Response, er
 // WithContextAsync sends an asynchronous request with context support.
 // This allows for timeout and cancellation control.
 func (ac *AsyncClient) WithContextAsync(ctx context.Context, method, url string, params url.Values, body io.Reader) <-chan AsyncResult {
-	resultChan := make(chan AsyncResult, 1)
-
-	go func() {
+	return ac.async(ctx, func() (*Response, error) {
 		// Create a request with context
 		req, err := NewRequestWithContext(ctx, method, url, body)
 		if err != nil {
-			resultChan <- AsyncResult{Resp: nil, Err: err}
-			return
+			return nil, err
 		}
 
 		// Send the request
 		resp, err := ac.Do(req.GetRequest())
 		if err != nil {
-			resultChan <- AsyncResult{Resp: nil, Err: err}
-			return
+			return nil, err
 		}
-
-		response := NewResponse(resp)
-		resultChan <- AsyncResult{Resp: response, Err: nil}
-	}()
-
-	return resultChan
+		return NewResponse(resp), nil
+	})
 }
 
 // WaitForAll waits for multiple async requests to complete and returns all results.
@@ -0,0 +1,263 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// ErrBatchAborted is the error of requests that were not sent because a
+// fail-fast batch stopped after an earlier failure.
+var ErrBatchAborted = errors.New("httpx: batch aborted after a failed request")
+
+// BatchMode controls how a batch reacts to failed requests.
+type BatchMode int
+
+const (
+	// BatchCollectAll sends every request and collects all results.
+	BatchCollectAll BatchMode = iota
+	// BatchFailFast stops the batch at the first request returning an error:
+	// requests in flight are cancelled and pending ones fail with ErrBatchAborted.
+	// Responses with error status codes are not failures.
+	BatchFailFast
+)
+
+// BatchOptions configures BatchAsyncContext and StreamAsync.
+type BatchOptions struct {
+	// Concurrency is the number of workers sending requests. Zero uses the
+	// client limit, or one worker per request if the client is unlimited.
+	Concurrency int
+	// Mode selects collect-all or fail-fast behaviour.
+	Mode BatchMode
+}
+
+// IndexedResult is an AsyncResult tagged with the position of its request in the batch.
+type IndexedResult struct {
+	AsyncResult
+	Index int
+}
+
+// NewAsyncClientWithLimit creates an AsyncClient that runs at most limit requests
+// at once across all of its asynchronous methods. Calls beyond the limit wait for
+// a free slot. A limit of zero or less means no limit.
+//
+// Example:
+//
+//	client := httpx.NewAsyncClientWithLimit(nil, 16)
+//	results := <-client.BatchAsync(requests) // never more than 16 connections
+func NewAsyncClientWithLimit(client *http.Client, limit int) *AsyncClient {
+	ac := NewAsyncClient(client)
+	if limit > 0 {
+		ac.sem = make(chan struct{}, limit)
+	}
+	return ac
+}
+
+// Limit returns the maximum number of requests in flight, zero if unlimited.
+func (ac *AsyncClient) Limit() int {
+	return cap(ac.sem)
+}
+
+// DoAsync sends req asynchronously using ctx for cancellation, and returns a
+// channel that will receive the result.
+func (ac *AsyncClient) DoAsync(ctx context.Context, req Request) <-chan AsyncResult {
+	return ac.async(ctx, func() (*Response, error) {
+		return ac.doRequest(ctx, req)
+	})
+}
+
+// GetAsyncContext sends an asynchronous GET request bound to ctx.
+func (ac *AsyncClient) GetAsyncContext(ctx context.Context, url string, params url.Values) <-chan AsyncResult {
+	return ac.async(ctx, func() (*Response, error) {
+		uri, err := ac.buildUrl(url, params)
+		if err != nil {
+			return nil, err
+		}
+		return ac.send(http.MethodGet, uri, nil, WithContext(ctx))
+	})
+}
+
+// PostAsyncContext sends an asynchronous POST request bound to ctx.
+func (ac *AsyncClient) PostAsyncContext(ctx context.Context, url string, body io.Reader, options ...RequestOption) <-chan AsyncResult {
+	return ac.sendAsync(ctx, http.MethodPost, url, body, options)
+}
+
+// PutAsyncContext sends an asynchronous PUT request bound to ctx.
+func (ac *AsyncClient) PutAsyncContext(ctx context.Context, url string, body io.Reader, options ...RequestOption) <-chan AsyncResult {
+	return ac.sendAsync(ctx, http.MethodPut, url, body, options)
+}
+
+// PatchAsyncContext sends an asynchronous PATCH request bound to ctx.
+func (ac *AsyncClient) PatchAsyncContext(ctx context.Context, url string, body io.Reader, options ...RequestOption) <-chan AsyncResult {
+	return ac.sendAsync(ctx, http.MethodPatch, url, body, options)
+}
+
+// DeleteAsyncContext sends an asynchronous DELETE request bound to ctx.
+func (ac *AsyncClient) DeleteAsyncContext(ctx context.Context, url string, options ...RequestOption) <-chan AsyncResult {
+	return ac.sendAsync(ctx, http.MethodDelete, url, nil, options)
+}
+
+// BatchAsyncContext sends requests with a bounded worker pool and returns a channel
+// that will receive all results in request order.
+//
+// Cancelling ctx stops the batch: requests in flight are cancelled and requests not
+// yet started fail with the context error. In BatchFailFast mode the first failure
+// stops the batch the same way, and requests not yet started fail with ErrBatchAborted.
+// Response bodies stay readable after the batch completes; the resources of the
+// batch are released once all of them are closed.
+//
+// Example:
+//
+//	results := <-client.BatchAsyncContext(ctx, requests, httpx.BatchOptions{
+//		Concurrency: 8,
+//		Mode:        httpx.BatchFailFast,
+//	})
+func (ac *AsyncClient) BatchAsyncContext(ctx context.Context, requests []Request, opts BatchOptions) <-chan []AsyncResult {
+	resultChan := make(chan []AsyncResult, 1)
+
+	go func() {
+		results := make([]AsyncResult, len(requests))
+		for result := range ac.StreamAsync(ctx, requests, opts) {
+			results[result.Index] = result.AsyncResult
+		}
+		resultChan <- results
+	}()
+
+	return resultChan
+}
+
+// StreamAsync sends requests with a bounded worker pool and returns a channel that
+// yields each result as soon as it completes, tagged with the index of its request.
+// The channel is closed after one result per request has been delivered.
+// Cancellation and BatchFailFast behave as in BatchAsyncContext.
+//
+// Example:
+//
+//	for result := range client.StreamAsync(ctx, requests, httpx.BatchOptions{Concurrency: 8}) {
+//		if result.Err != nil {
+//			log.Printf("request %d failed: %v", result.Index, result.Err)
+//			continue
+//		}
+//		handle(result.Index, result.Resp)
+//	}
+func (ac *AsyncClient) StreamAsync(ctx context.Context, requests []Request, opts BatchOptions) <-chan IndexedResult {
+	return ac.stream(ctx, requests, opts, ac.doRequest)
+}
+
+// stream runs exec for every request on a pool of workers and delivers the
+// results as they complete. Requests not started before ctx is done fail with
+// the context cause.
+func (ac *AsyncClient) stream(ctx context.Context, requests []Request, opts BatchOptions,
+	exec func(context.Context, Request) (*Response, error)) <-chan IndexedResult {
+	workers := opts.Concurrency
+	if workers <= 0 {
+		workers = ac.Limit()
+	}
+	if workers <= 0 || workers > len(requests) {
+		workers = len(requests)
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	out := make(chan IndexedResult, len(requests))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	// Bodies are read after their request completes, so ctx is only cancelled
+	// once the batch is done and every returned body is closed.
+	var bodies sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				resp, err := ac.run(ctx, func() (*Response, error) {
+					return exec(ctx, requests[i])
+				})
+				if err != nil && opts.Mode == BatchFailFast {
+					if context.Cause(ctx) == ErrBatchAborted && errors.Is(err, context.Canceled) {
+						err = ErrBatchAborted
+					}
+					cancel(ErrBatchAborted)
+				}
+				if resp != nil && resp.Body != nil {
+					bodies.Add(1)
+					resp.Body = &releaseBody{ReadCloser: resp.Body, release: sync.OnceFunc(bodies.Done)}
+				}
+				out <- IndexedResult{AsyncResult: AsyncResult{Resp: resp, Err: err}, Index: i}
+			}
+		}()
+	}
+
+	go func() {
+		for i := range requests {
+			indexes <- i
+		}
+		close(indexes)
+		wg.Wait()
+		close(out)
+		bodies.Wait()
+		cancel(nil)
+	}()
+	return out
+}
+
+// releaseBody calls release when the body is closed.
+type releaseBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
+
+// async runs f in a new goroutine once a concurrency slot is available and
+// returns a channel that will receive its result.
+func (ac *AsyncClient) async(ctx context.Context, f func() (*Response, error)) <-chan AsyncResult {
+	resultChan := make(chan AsyncResult, 1)
+
+	go func() {
+		resp, err := ac.run(ctx, f)
+		resultChan <- AsyncResult{Resp: resp, Err: err}
+	}()
+
+	return resultChan
+}
+
+// run calls f while holding a concurrency slot. It fails without calling f if
+// ctx is done before a slot is available or before f starts.
+func (ac *AsyncClient) run(ctx context.Context, f func() (*Response, error)) (*Response, error) {
+	if ac.sem != nil {
+		select {
+		case ac.sem <- struct{}{}:
+			defer func() { <-ac.sem }()
+		case <-ctx.Done():
+			return nil, context.Cause(ctx)
+		}
+	}
+	if ctx.Err() != nil {
+		return nil, context.Cause(ctx)
+	}
+	return f()
+}
+
+// sendAsync sends a request built from method, url, body and options, bound to ctx.
+func (ac *AsyncClient) sendAsync(ctx context.Context, method, url string, body io.Reader, options []RequestOption) <-chan AsyncResult {
+	return ac.async(ctx, func() (*Response, error) {
+		options = append(options[:len(options):len(options)], WithContext(ctx))
+		return ac.send(method, url, body, options...)
+	})
+}
+
+// doRequest sends req with ctx as its context.
+func (ac *AsyncClient) doRequest(ctx context.Context, req Request) (*Response, error) {
+	resp, err := ac.Do(req.GetRequest().WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return NewResponse(resp), nil
+}
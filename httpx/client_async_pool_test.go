@@ -0,0 +1,212 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newConcurrencyServer returns a server that tracks the peak number of concurrent
+// requests and fails requests whose path ends with "/fail".
+func newConcurrencyServer(delay time.Duration, peak *int32) *httptest.Server {
+	var current int32
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&current, 1)
+		defer atomic.AddInt32(&current, -1)
+		for {
+			p := atomic.LoadInt32(peak)
+			if n <= p || atomic.CompareAndSwapInt32(peak, p, n) {
+				break
+			}
+		}
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/fail") {
+			hj, _ := w.(http.Hijacker)
+			conn, _, _ := hj.Hijack()
+			_ = conn.Close()
+			return
+		}
+		_, _ = w.Write([]byte(r.URL.Path))
+	}))
+}
+
+func newBatchRequests(t *testing.T, base string, paths ...string) []Request {
+	requests := make([]Request, len(paths))
+	for i, path := range paths {
+		req, err := NewRequest(http.MethodGet, base+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		requests[i] = req
+	}
+	return requests
+}
+
+func TestAsyncClient_Limit(t *testing.T) {
+	var peak int32
+	server := newConcurrencyServer(20*time.Millisecond, &peak)
+	defer server.Close()
+
+	client := NewAsyncClientWithLimit(&http.Client{}, 3)
+	if client.Limit() != 3 {
+		t.Fatalf("Expected limit 3, got %d", client.Limit())
+	}
+
+	paths := make([]string, 12)
+	for i := range paths {
+		paths[i] = fmt.Sprintf("/%d", i)
+	}
+	results := <-client.BatchAsync(newBatchRequests(t, server.URL, paths...))
+	for i, result := range results {
+		if result.Err != nil {
+			t.Fatalf("Request %d failed: %v", i, result.Err)
+		}
+		if body, _ := result.Resp.String(); body != paths[i] {
+			t.Errorf("Result %d out of order: %q", i, body)
+		}
+	}
+	if peak > 3 {
+		t.Errorf("Expected at most 3 concurrent requests, got %d", peak)
+	}
+
+	peak = 0
+	chans := make([]<-chan AsyncResult, 6)
+	for i := range chans {
+		chans[i] = client.GetAsync(server.URL+"/single", nil)
+	}
+	for _, result := range WaitForAll(chans) {
+		if result.Err != nil {
+			t.Fatal(result.Err)
+		}
+		_ = result.Resp.Close()
+	}
+	if peak > 3 {
+		t.Errorf("Expected GetAsync to respect the limit, got %d concurrent", peak)
+	}
+}
+
+func TestAsyncClient_BatchAsyncContext_FailFast(t *testing.T) {
+	var peak int32
+	server := newConcurrencyServer(10*time.Millisecond, &peak)
+	defer server.Close()
+
+	client := NewAsyncClient(&http.Client{})
+	requests := newBatchRequests(t, server.URL, "/fail", "/a", "/b", "/c", "/d", "/e")
+	results := <-client.BatchAsyncContext(context.Background(), requests, BatchOptions{
+		Concurrency: 1,
+		Mode:        BatchFailFast,
+	})
+
+	if results[0].Err == nil {
+		t.Fatal("First request should fail")
+	}
+	for i, result := range results[1:] {
+		if !errors.Is(result.Err, ErrBatchAborted) {
+			t.Errorf("Request %d: expected ErrBatchAborted, got %v", i+1, result.Err)
+		}
+	}
+}
+
+func TestAsyncClient_BatchAsyncContext_CollectAll(t *testing.T) {
+	var peak int32
+	server := newConcurrencyServer(time.Millisecond, &peak)
+	defer server.Close()
+
+	client := NewAsyncClient(&http.Client{})
+	requests := newBatchRequests(t, server.URL, "/a", "/fail", "/b")
+	results := <-client.BatchAsyncContext(context.Background(), requests, BatchOptions{Concurrency: 2})
+
+	if results[0].Err != nil || results[2].Err != nil {
+		t.Errorf("Successful requests should not fail: %v, %v", results[0].Err, results[2].Err)
+	}
+	if results[1].Err == nil {
+		t.Error("Failing request should report its error")
+	}
+}
+
+func TestAsyncClient_StreamAsync(t *testing.T) {
+	var peak int32
+	server := newConcurrencyServer(5*time.Millisecond, &peak)
+	defer server.Close()
+
+	client := NewAsyncClient(&http.Client{})
+	paths := []string{"/0", "/1", "/2", "/3", "/4"}
+	seen := make(map[int]bool)
+	for result := range client.StreamAsync(context.Background(), newBatchRequests(t, server.URL, paths...), BatchOptions{Concurrency: 2}) {
+		if result.Err != nil {
+			t.Fatal(result.Err)
+		}
+		if body, _ := result.Resp.String(); body != paths[result.Index] {
+			t.Errorf("Index %d carries body %q", result.Index, body)
+		}
+		seen[result.Index] = true
+	}
+	if len(seen) != len(paths) {
+		t.Errorf("Expected %d results, got %d", len(paths), len(seen))
+	}
+	if peak > 2 {
+		t.Errorf("Expected at most 2 concurrent requests, got %d", peak)
+	}
+}
+
+func TestAsyncClient_ContextCancel(t *testing.T) {
+	var peak int32
+	server := newConcurrencyServer(time.Second, &peak)
+	defer server.Close()
+
+	client := NewAsyncClientWithLimit(&http.Client{}, 1)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	first := client.GetAsyncContext(ctx, server.URL+"/slow", nil)
+	second := client.PostAsyncContext(ctx, server.URL+"/queued", strings.NewReader("x"))
+	for _, result := range WaitForAll([]<-chan AsyncResult{first, second}) {
+		if !errors.Is(result.Err, context.DeadlineExceeded) {
+			t.Errorf("Expected deadline exceeded, got %v", result.Err)
+		}
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Error("Cancellation should not wait for the slow request")
+	}
+
+	req, _ := NewRequest(http.MethodGet, server.URL+"/x", nil)
+	cancelled, cancelNow := context.WithCancel(context.Background())
+	cancelNow()
+	if result := <-client.DoAsync(cancelled, req); !errors.Is(result.Err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", result.Err)
+	}
+}
+
+func TestAsyncClient_BatchBodiesReadableAfterBatch(t *testing.T) {
+	payload := strings.Repeat("x", 1<<20)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, payload)
+	}))
+	defer server.Close()
+
+	client := NewAsyncClient(&http.Client{})
+	results := <-client.BatchAsyncContext(context.Background(), newBatchRequests(t, server.URL, "/a", "/b"), BatchOptions{})
+	// Give the batch time to finish its bookkeeping before the bodies are read.
+	time.Sleep(50 * time.Millisecond)
+	for i, result := range results {
+		if result.Err != nil {
+			t.Fatalf("Request %d failed: %v", i, result.Err)
+		}
+		body, err := result.Resp.String()
+		if err != nil || len(body) != len(payload) {
+			t.Errorf("Body %d should be readable after the batch, got %d bytes (%v)", i, len(body), err)
+		}
+	}
+}
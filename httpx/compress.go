@@ -0,0 +1,272 @@
+package httpx
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Content codings supported by CompressionTransport and WithCompressedBody.
+const (
+	EncodingGzip    = "gzip"
+	EncodingDeflate = "deflate"
+	EncodingZstd    = "zstd"
+	EncodingBrotli  = "br"
+)
+
+// acceptEncoding is the Accept-Encoding header sent by CompressionTransport.
+const acceptEncoding = "gzip, deflate, br, zstd"
+
+// ErrUnsupportedEncoding is the error of requests and responses using a content
+// coding other than gzip, deflate, zstd, br or identity.
+var ErrUnsupportedEncoding = errors.New("httpx: unsupported content encoding")
+
+// CompressionTransport is an http.RoundTripper that compresses request bodies with
+// Encoding and transparently decodes gzip, deflate, zstd and br responses.
+//
+// Decoded responses have their Content-Encoding and Content-Length headers removed
+// and Uncompressed set, as Go does for gzip. Requests that already carry an
+// Accept-Encoding or Content-Encoding header are left to the caller.
+type CompressionTransport struct {
+	// Encoding compresses request bodies, none if empty.
+	Encoding string
+	// Transport sends the requests, http.DefaultTransport if nil.
+	Transport http.RoundTripper
+}
+
+// NewCompressionTransport creates a CompressionTransport compressing request bodies
+// with encoding, none if empty, and sending them with next, http.DefaultTransport if nil.
+func NewCompressionTransport(encoding string, next http.RoundTripper) *CompressionTransport {
+	return &CompressionTransport{Encoding: encoding, Transport: next}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *CompressionTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport := t.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	decode := req.Header.Get("Accept-Encoding") == ""
+	compress := t.Encoding != "" && req.Body != nil && req.Body != http.NoBody && req.Header.Get("Content-Encoding") == ""
+	if decode || compress {
+		req = req.Clone(req.Context())
+	}
+	if decode {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	if compress {
+		if err := compressBody(req, t.Encoding); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil || !decode {
+		return resp, err
+	}
+	if err := decodeBody(resp); err != nil {
+		_ = resp.Body.Close()
+		return nil, err
+	}
+	return resp, nil
+}
+
+// WithCompression returns a copy of the client that compresses request bodies with
+// encoding, none if empty, and decodes compressed responses. The original client
+// is not modified.
+//
+// Example:
+//
+//	client := httpx.NewRestClient(nil).WithCompression(httpx.EncodingZstd)
+//	resp, err := client.PostJson("https://ingest.example.com/batch", bytes.NewReader(batch))
+func (c *RestClient) WithCompression(encoding string) *RestClient {
+	return c.withTransport(func(next http.RoundTripper) http.RoundTripper {
+		return NewCompressionTransport(encoding, next)
+	})
+}
+
+// WithCompressedBody returns a RequestOption that compresses the request body with
+// encoding and sets the Content-Encoding header. The body is compressed while it is
+// sent, so it is never held in memory in full. An unsupported encoding makes the
+// request fail with ErrUnsupportedEncoding when it is sent.
+func WithCompressedBody(encoding string) RequestOption {
+	return func(req Request) {
+		hreq := req.GetRequest()
+		if err := compressBody(hreq, encoding); err != nil {
+			hreq.Body = io.NopCloser(errReader{err})
+			hreq.GetBody = nil
+			hreq.ContentLength = -1
+		}
+	}
+}
+
+// compressBody replaces the body of req by a compressed stream.
+func compressBody(req *http.Request, encoding string) error {
+	switch encoding {
+	case EncodingGzip, EncodingDeflate, EncodingZstd, EncodingBrotli:
+	default:
+		return fmt.Errorf("%w: %q", ErrUnsupportedEncoding, encoding)
+	}
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+	req.Body = newCompressingReader(req.Body, encoding)
+	if getBody := req.GetBody; getBody != nil {
+		req.GetBody = func() (io.ReadCloser, error) {
+			body, err := getBody()
+			if err != nil {
+				return nil, err
+			}
+			return newCompressingReader(body, encoding), nil
+		}
+	}
+	req.ContentLength = -1
+	req.Header.Set("Content-Encoding", encoding)
+	req.Header.Del("Content-Length")
+	return nil
+}
+
+// decodeBody replaces the body of resp by its decoded content.
+func decodeBody(resp *http.Response) error {
+	header := resp.Header.Get("Content-Encoding")
+	if header == "" || resp.Body == nil || resp.Body == http.NoBody {
+		return nil
+	}
+	codings := strings.Split(header, ",")
+	body := resp.Body
+	// Codings are listed in the order they were applied.
+	for i := len(codings) - 1; i >= 0; i-- {
+		coding := strings.ToLower(strings.TrimSpace(codings[i]))
+		if coding == "identity" || coding == "" {
+			continue
+		}
+		decoded, err := newDecoder(body, coding)
+		if err != nil {
+			return err
+		}
+		body = &decodedBody{ReadCloser: decoded, underlying: body}
+	}
+	resp.Body = body
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return nil
+}
+
+// newEncoder returns a writer compressing into w with encoding.
+func newEncoder(w io.Writer, encoding string) (io.WriteCloser, error) {
+	switch encoding {
+	case EncodingGzip:
+		return gzip.NewWriter(w), nil
+	case EncodingDeflate:
+		// HTTP deflate is the zlib format (RFC 9110 section 8.4.1.2).
+		return zlib.NewWriter(w), nil
+	case EncodingZstd:
+		return zstd.NewWriter(w, zstd.WithEncoderConcurrency(1))
+	case EncodingBrotli:
+		return brotli.NewWriter(w), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedEncoding, encoding)
+	}
+}
+
+// newDecoder returns a reader decoding r, compressed with encoding.
+func newDecoder(r io.Reader, encoding string) (io.ReadCloser, error) {
+	switch encoding {
+	case EncodingGzip, "x-gzip":
+		return gzip.NewReader(r)
+	case EncodingDeflate:
+		return newZlibOrRawReader(r)
+	case EncodingZstd:
+		d, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return d.IOReadCloser(), nil
+	case EncodingBrotli:
+		return io.NopCloser(brotli.NewReader(r)), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedEncoding, encoding)
+	}
+}
+
+// decodedBody closes both the decoder and the body it reads from.
+type decodedBody struct {
+	io.ReadCloser
+	underlying io.Closer
+}
+
+func (b *decodedBody) Close() error {
+	err := b.ReadCloser.Close()
+	if uerr := b.underlying.Close(); err == nil {
+		err = uerr
+	}
+	return err
+}
+
+// compressingReader compresses src on the fly. The compressing goroutine starts on
+// the first Read, so unsent requests do not leak it.
+type compressingReader struct {
+	src      io.ReadCloser
+	encoding string
+	once     sync.Once
+	pr       *io.PipeReader
+	pw       *io.PipeWriter
+}
+
+func newCompressingReader(src io.ReadCloser, encoding string) *compressingReader {
+	pr, pw := io.Pipe()
+	return &compressingReader{src: src, encoding: encoding, pr: pr, pw: pw}
+}
+
+func (r *compressingReader) Read(p []byte) (int, error) {
+	r.once.Do(func() { go r.compress() })
+	return r.pr.Read(p)
+}
+
+func (r *compressingReader) Close() error {
+	_ = r.pr.Close()
+	return r.src.Close()
+}
+
+func (r *compressingReader) compress() {
+	enc, err := newEncoder(r.pw, r.encoding)
+	if err == nil {
+		_, err = io.Copy(enc, r.src)
+		if cerr := enc.Close(); err == nil {
+			err = cerr
+		}
+	}
+	_ = r.pw.CloseWithError(err)
+}
+
+// newZlibOrRawReader decodes HTTP deflate content. Some servers send raw DEFLATE
+// data instead of the zlib format, so the zlib header is checked first.
+func newZlibOrRawReader(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(2)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if len(header) == 2 && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
+
+// errReader is a reader failing with err.
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) { return 0, r.err }
@@ -0,0 +1,141 @@
+package httpx
+
+import (
+	"bytes"
+	"compress/flate"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// compressionServer decodes request bodies and answers with the body encoded
+// as requested by the "encoding" query parameter.
+func compressionServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body io.Reader = r.Body
+		if coding := r.Header.Get("Content-Encoding"); coding != "" {
+			decoded, err := newDecoder(r.Body, coding)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+				return
+			}
+			body = decoded
+		}
+		data, err := io.ReadAll(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("X-Request-Encoding", r.Header.Get("Content-Encoding"))
+		w.Header().Set("X-Accept-Encoding", r.Header.Get("Accept-Encoding"))
+
+		coding := r.URL.Query().Get("encoding")
+		if coding == "" {
+			_, _ = w.Write(data)
+			return
+		}
+		w.Header().Set("Content-Encoding", coding)
+		if coding == "raw-deflate" {
+			w.Header().Set("Content-Encoding", EncodingDeflate)
+			fw, _ := flate.NewWriter(w, flate.DefaultCompression)
+			_, _ = fw.Write(data)
+			_ = fw.Close()
+			return
+		}
+		enc, err := newEncoder(w, coding)
+		if err != nil {
+			// Codings the test cannot produce are sent as is.
+			_, _ = w.Write(data)
+			return
+		}
+		_, _ = enc.Write(data)
+		_ = enc.Close()
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRestClient_WithCompression(t *testing.T) {
+	server := compressionServer(t)
+	payload := strings.Repeat(`{"event":"click","user":42}`+"\n", 1000)
+
+	for _, encoding := range []string{EncodingGzip, EncodingDeflate, EncodingZstd, EncodingBrotli} {
+		t.Run(encoding, func(t *testing.T) {
+			client := NewRestClient(&http.Client{}).WithCompression(encoding)
+			resp, err := client.PostJson(server.URL+"?encoding="+encoding, strings.NewReader(payload))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := resp.HeaderValue("X-Request-Encoding"); got != encoding {
+				t.Errorf("Expected request Content-Encoding %q, got %q", encoding, got)
+			}
+			if got := resp.HeaderValue("X-Accept-Encoding"); got != acceptEncoding {
+				t.Errorf("Expected Accept-Encoding %q, got %q", acceptEncoding, got)
+			}
+			if resp.HeaderValue("Content-Encoding") != "" || !resp.Uncompressed {
+				t.Error("Decoded responses should drop Content-Encoding")
+			}
+			if body, err := resp.String(); err != nil || body != payload {
+				t.Errorf("Round trip mangled the payload: %v", err)
+			}
+		})
+	}
+}
+
+func TestCompressionTransport_DecodeOnly(t *testing.T) {
+	server := compressionServer(t)
+	client := NewRestClient(&http.Client{}).WithCompression("")
+
+	for _, encoding := range []string{"raw-deflate", "identity"} {
+		resp, err := client.Post(server.URL+"?encoding="+encoding, strings.NewReader("plain"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := resp.HeaderValue("X-Request-Encoding"); got != "" {
+			t.Errorf("Request bodies should not be compressed, got %q", got)
+		}
+		if body, _ := resp.String(); body != "plain" {
+			t.Errorf("Expected decoded %s body, got %q", encoding, body)
+		}
+	}
+
+	resp, err := client.Post(server.URL+"?encoding=compress", strings.NewReader("x"))
+	if !errors.Is(err, ErrUnsupportedEncoding) {
+		t.Errorf("Expected ErrUnsupportedEncoding, got %v %v", resp, err)
+	}
+}
+
+func TestCompressionTransport_RespectsCallerHeaders(t *testing.T) {
+	server := compressionServer(t)
+	client := NewRestClient(&http.Client{}).WithCompression(EncodingGzip)
+
+	resp, err := client.Post(server.URL+"?encoding=gzip", strings.NewReader("data"),
+		WithHeader("Accept-Encoding", "gzip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := resp.Bytes()
+	if resp.HeaderValue("Content-Encoding") != EncodingGzip || bytes.Equal(raw, []byte("data")) {
+		t.Error("Responses to requests with their own Accept-Encoding should not be decoded")
+	}
+}
+
+func TestWithCompressedBody(t *testing.T) {
+	server := compressionServer(t)
+
+	resp, err := NewRestClient(&http.Client{}).Post(server.URL, strings.NewReader("hello"), WithCompressedBody(EncodingZstd))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body, _ := resp.String(); body != "hello" || resp.HeaderValue("X-Request-Encoding") != EncodingZstd {
+		t.Errorf("Expected a zstd request body, got %q", body)
+	}
+
+	if _, err := NewRestClient(&http.Client{}).Post(server.URL, strings.NewReader("hello"), WithCompressedBody("lzma")); !errors.Is(err, ErrUnsupportedEncoding) {
+		t.Errorf("Expected ErrUnsupportedEncoding, got %v", err)
+	}
+}
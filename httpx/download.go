@@ -0,0 +1,384 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/go4x/goal/ciphers/hash"
+)
+
+const (
+	// downloadPartSuffix is appended to the destination path for the temporary file.
+	downloadPartSuffix = ".part"
+	// downloadMetaSuffix is appended to the temporary file path for the file that
+	// stores the validator (ETag or Last-Modified) used to resume the download.
+	downloadMetaSuffix = ".meta"
+	// defaultMinSegmentSize is the smallest segment used for parallel downloads.
+	defaultMinSegmentSize = 1 << 20
+)
+
+// ErrChecksumMismatch is returned by Download when the downloaded content does not
+// match DownloadOptions.Checksum.
+var ErrChecksumMismatch = errors.New("httpx: checksum mismatch")
+
+// DownloadOptions configures RestClient.Download. The zero value downloads in a
+// single stream without verification or progress reporting.
+type DownloadOptions struct {
+	// Checksum is the expected hex-encoded SHA-256 of the file. When set, the
+	// download is verified before it is moved into place.
+	Checksum string
+	// Progress is called after every write with the number of bytes downloaded so
+	// far and the total size, or -1 if the size is unknown. Calls are serialized.
+	Progress func(downloaded, total int64)
+	// Segments is the number of parallel ranged requests used for large files.
+	// Values below 2 disable segmentation. Segmented downloads are only used when
+	// the server advertises byte ranges and are not resumable.
+	Segments int
+	// MinSegmentSize is the smallest segment size; files smaller than
+	// Segments*MinSegmentSize are downloaded in a single stream. Defaults to 1MiB.
+	MinSegmentSize int64
+	// RequestOptions are applied to every request made by the download.
+	RequestOptions []RequestOption
+}
+
+// Download fetches url into dstPath.
+//
+// The body is streamed to dstPath+".part" and renamed to dstPath once it is complete
+// and verified, so dstPath never contains a partial file. If a previous attempt left
+// a partial file behind, the download resumes with a Range request guarded by
+// If-Range, and restarts from scratch if the resource has changed since.
+//
+// Example:
+//
+//	err := client.Download(ctx, "https://example.com/app.tar.gz", "/tmp/app.tar.gz",
+//		&httpx.DownloadOptions{
+//			Checksum: "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+//			Progress: func(done, total int64) { fmt.Printf("\r%d/%d", done, total) },
+//		})
+func (c *RestClient) Download(ctx context.Context, url string, dstPath string, opts *DownloadOptions) error {
+	if opts == nil {
+		opts = &DownloadOptions{}
+	}
+	d := &download{
+		client:  c,
+		ctx:     ctx,
+		url:     url,
+		part:    dstPath + downloadPartSuffix,
+		opts:    opts,
+		total:   -1,
+		minSize: opts.MinSegmentSize,
+	}
+	if d.minSize <= 0 {
+		d.minSize = defaultMinSegmentSize
+	}
+
+	var err error
+	if opts.Segments > 1 {
+		err = d.segmented()
+	} else {
+		err = d.single()
+	}
+	if err != nil {
+		return err
+	}
+
+	if opts.Checksum != "" {
+		sum, err := hash.SHA256File(d.part)
+		if err != nil {
+			return err
+		}
+		if !strings.EqualFold(sum, opts.Checksum) {
+			d.cleanup()
+			return fmt.Errorf("%w: got %s, want %s", ErrChecksumMismatch, sum, opts.Checksum)
+		}
+	}
+	if err := os.Rename(d.part, dstPath); err != nil {
+		return err
+	}
+	_ = os.Remove(d.part + downloadMetaSuffix)
+	return nil
+}
+
+// download holds the state of a single Download call.
+type download struct {
+	client  *RestClient
+	ctx     context.Context
+	url     string
+	part    string
+	opts    *DownloadOptions
+	minSize int64
+
+	total      int64
+	downloaded atomic.Int64
+	progressMu sync.Mutex
+}
+
+// single downloads the resource in one stream, resuming a previous partial file.
+func (d *download) single() error {
+	offset, validator := d.resumeState()
+	resp, err := d.get(d.ctx, offset, -1, validator)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		start, _, size, ok := parseContentRange(resp.Header.Get("Content-Range"))
+		if !ok || start != offset {
+			return fmt.Errorf("httpx: unexpected Content-Range %q", resp.Header.Get("Content-Range"))
+		}
+		d.total = size
+		flags |= os.O_APPEND
+	case http.StatusOK:
+		offset = 0
+		if resp.ContentLength >= 0 {
+			d.total = resp.ContentLength
+		}
+		flags |= os.O_TRUNC
+	case http.StatusRequestedRangeNotSatisfiable:
+		// The partial file may already hold the whole resource, provided it is
+		// still the same resource. Otherwise it is stale and the download restarts.
+		_, _, size, ok := parseContentRange(resp.Header.Get("Content-Range"))
+		if ok && size == offset && responseValidator(resp) == validator {
+			d.total = size
+			d.addProgress(offset)
+			return nil
+		}
+		_ = resp.Body.Close()
+		d.cleanup()
+		return d.single()
+	default:
+		return fmt.Errorf("httpx: download failed: %s", resp.Status)
+	}
+
+	if err := d.saveValidator(resp); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(d.part, flags, 0o644)
+	if err != nil {
+		return err
+	}
+	d.addProgress(offset)
+	_, err = io.Copy(&progressWriter{w: f, d: d}, resp.Body)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// segmented downloads the resource with parallel ranged requests, falling back to
+// a single stream when the server does not support ranges or the file is small.
+func (d *download) segmented() error {
+	req, err := NewRequestWithContext(d.ctx, http.MethodHead, d.url, nil, d.opts.RequestOptions...)
+	if err != nil {
+		return err
+	}
+	resp, err := d.client.Do(req.GetRequest())
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+
+	size := resp.ContentLength
+	segments := int64(d.opts.Segments)
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Accept-Ranges") != "bytes" ||
+		size < segments*d.minSize {
+		return d.single()
+	}
+	validator := responseValidator(resp)
+
+	d.total = size
+	// The preallocated file is zero-filled, so a validator left by an earlier
+	// single stream must not let it be resumed later.
+	_ = os.Remove(d.part + downloadMetaSuffix)
+	f, err := os.OpenFile(d.part, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	if err := f.Truncate(size); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(d.ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	chunk := size / segments
+	for i := int64(0); i < segments; i++ {
+		start, end := i*chunk, (i+1)*chunk-1
+		if i == segments-1 {
+			end = size - 1
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := d.fetchSegment(ctx, f, start, end, validator); err != nil {
+				errOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		_ = f.Close()
+		d.cleanup()
+		return firstErr
+	}
+	return f.Close()
+}
+
+// fetchSegment downloads bytes [start, end] into f at the same offset.
+func (d *download) fetchSegment(ctx context.Context, f *os.File, start, end int64, validator string) error {
+	resp, err := d.get(ctx, start, end, validator)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusPartialContent {
+		return fmt.Errorf("httpx: segment %d-%d failed: %s", start, end, resp.Status)
+	}
+	w := &progressWriter{w: io.NewOffsetWriter(f, start), d: d}
+	n, err := io.Copy(w, resp.Body)
+	if err == nil && n != end-start+1 {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// get sends a GET request for the byte range [start, end]; end < 0 means until
+// the end of the resource, and start == 0 with end < 0 requests the whole resource.
+func (d *download) get(ctx context.Context, start, end int64, validator string) (*http.Response, error) {
+	req, err := NewRequestWithContext(ctx, http.MethodGet, d.url, nil, d.opts.RequestOptions...)
+	if err != nil {
+		return nil, err
+	}
+	if start > 0 || end >= 0 {
+		rng := "bytes=" + strconv.FormatInt(start, 10) + "-"
+		if end >= 0 {
+			rng += strconv.FormatInt(end, 10)
+		}
+		req.WithHeader("Range", rng)
+		if validator != "" {
+			req.WithHeader("If-Range", validator)
+		}
+	}
+	return d.client.Do(req.GetRequest())
+}
+
+// resumeState returns the size of an existing partial file and the validator it
+// was downloaded with. Without a validator the partial file cannot be trusted and
+// the download restarts.
+func (d *download) resumeState() (int64, string) {
+	info, err := os.Stat(d.part)
+	if err != nil || info.Size() == 0 {
+		return 0, ""
+	}
+	meta, err := os.ReadFile(d.part + downloadMetaSuffix)
+	if err != nil || len(meta) == 0 {
+		return 0, ""
+	}
+	return info.Size(), string(meta)
+}
+
+// saveValidator records the response validator next to the partial file.
+func (d *download) saveValidator(resp *http.Response) error {
+	validator := responseValidator(resp)
+	if validator == "" {
+		_ = os.Remove(d.part + downloadMetaSuffix)
+		return nil
+	}
+	return os.WriteFile(d.part+downloadMetaSuffix, []byte(validator), 0o644)
+}
+
+// cleanup removes the partial file and its metadata.
+func (d *download) cleanup() {
+	_ = os.Remove(d.part)
+	_ = os.Remove(d.part + downloadMetaSuffix)
+}
+
+// addProgress records n downloaded bytes and reports progress.
+func (d *download) addProgress(n int64) {
+	done := d.downloaded.Add(n)
+	if d.opts.Progress == nil {
+		return
+	}
+	d.progressMu.Lock()
+	defer d.progressMu.Unlock()
+	d.opts.Progress(done, d.total)
+}
+
+// progressWriter forwards writes and reports their size to the download.
+type progressWriter struct {
+	w io.Writer
+	d *download
+}
+
+func (pw *progressWriter) Write(p []byte) (int, error) {
+	n, err := pw.w.Write(p)
+	if n > 0 {
+		pw.d.addProgress(int64(n))
+	}
+	return n, err
+}
+
+// responseValidator returns the strong ETag of resp, or its Last-Modified date,
+// suitable for an If-Range header.
+func responseValidator(resp *http.Response) string {
+	if etag := resp.Header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return resp.Header.Get("Last-Modified")
+}
+
+// parseContentRange parses "bytes start-end/size" and "bytes */size".
+// size is -1 when the server reports an unknown length.
+func parseContentRange(s string) (start, end, size int64, ok bool) {
+	rest, found := strings.CutPrefix(s, "bytes ")
+	if !found {
+		return 0, 0, 0, false
+	}
+	rng, total, found := strings.Cut(rest, "/")
+	if !found {
+		return 0, 0, 0, false
+	}
+	size = -1
+	if total != "*" {
+		n, err := strconv.ParseInt(total, 10, 64)
+		if err != nil {
+			return 0, 0, 0, false
+		}
+		size = n
+	}
+	if rng == "*" {
+		return 0, 0, size, true
+	}
+	first, last, found := strings.Cut(rng, "-")
+	if !found {
+		return 0, 0, 0, false
+	}
+	var err error
+	if start, err = strconv.ParseInt(first, 10, 64); err != nil {
+		return 0, 0, 0, false
+	}
+	if end, err = strconv.ParseInt(last, 10, 64); err != nil {
+		return 0, 0, 0, false
+	}
+	return start, end, size, true
+}
@@ -0,0 +1,267 @@
+package httpx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go4x/goal/ciphers/hash"
+)
+
+// newDownloadServer serves content with range support and counts the bytes sent.
+func newDownloadServer(content []byte, etag string, sent *int64, ranges *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Range") != "" && ranges != nil {
+			atomic.AddInt32(ranges, 1)
+		}
+		w.Header().Set("ETag", etag)
+		cw := &countingResponseWriter{ResponseWriter: w, n: sent}
+		http.ServeContent(cw, r, "file.bin", time.Time{}, bytes.NewReader(content))
+	}))
+}
+
+type countingResponseWriter struct {
+	http.ResponseWriter
+	n *int64
+}
+
+func (w *countingResponseWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	atomic.AddInt64(w.n, int64(n))
+	return n, err
+}
+
+func TestRestClient_Download(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 1000)
+	var sent int64
+	server := newDownloadServer(content, `"v1"`, &sent, nil)
+	defer server.Close()
+
+	dst := filepath.Join(t.TempDir(), "file.bin")
+	var lastDone, lastTotal int64
+	err := NewRestClient(nil).Download(context.Background(), server.URL, dst, &DownloadOptions{
+		Checksum: hash.SHA256(string(content)),
+		Progress: func(done, total int64) { lastDone, lastTotal = done, total },
+	})
+	if err != nil {
+		t.Fatalf("Download failed: %v", err)
+	}
+
+	got, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Error("Downloaded content does not match")
+	}
+	if lastDone != int64(len(content)) || lastTotal != int64(len(content)) {
+		t.Errorf("Expected final progress %d/%d, got %d/%d", len(content), len(content), lastDone, lastTotal)
+	}
+	for _, leftover := range []string{dst + ".part", dst + ".part.meta"} {
+		if _, err := os.Stat(leftover); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed", leftover)
+		}
+	}
+}
+
+func TestRestClient_Download_Resume(t *testing.T) {
+	content := bytes.Repeat([]byte("abcdefghij"), 1000)
+	var sent int64
+	server := newDownloadServer(content, `"v1"`, &sent, nil)
+	defer server.Close()
+
+	dst := filepath.Join(t.TempDir(), "file.bin")
+	if err := os.WriteFile(dst+".part", content[:4000], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dst+".part.meta", []byte(`"v1"`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := NewRestClient(nil).Download(context.Background(), server.URL, dst, &DownloadOptions{
+		Checksum: hash.SHA256(string(content)),
+	})
+	if err != nil {
+		t.Fatalf("Download failed: %v", err)
+	}
+	if sent != int64(len(content)-4000) {
+		t.Errorf("Expected only %d remaining bytes to be sent, got %d", len(content)-4000, sent)
+	}
+	got, _ := os.ReadFile(dst)
+	if !bytes.Equal(got, content) {
+		t.Error("Resumed content does not match")
+	}
+}
+
+func TestRestClient_Download_ResumeChanged(t *testing.T) {
+	content := bytes.Repeat([]byte("abcdefghij"), 1000)
+	var sent int64
+	server := newDownloadServer(content, `"v2"`, &sent, nil)
+	defer server.Close()
+
+	dst := filepath.Join(t.TempDir(), "file.bin")
+	_ = os.WriteFile(dst+".part", []byte(strings.Repeat("x", 4000)), 0o644)
+	_ = os.WriteFile(dst+".part.meta", []byte(`"v1"`), 0o644)
+
+	if err := NewRestClient(nil).Download(context.Background(), server.URL, dst, nil); err != nil {
+		t.Fatalf("Download failed: %v", err)
+	}
+	got, _ := os.ReadFile(dst)
+	if !bytes.Equal(got, content) {
+		t.Error("Expected a full download after the resource changed")
+	}
+}
+
+func TestRestClient_Download_ResumeComplete(t *testing.T) {
+	content := bytes.Repeat([]byte("abcdefghij"), 1000)
+	tests := []struct {
+		name    string
+		etag    string
+		partial []byte
+	}{
+		{"same resource", `"v1"`, content},
+		{"changed resource", `"v2"`, bytes.Repeat([]byte{0}, len(content))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The server ignores If-Range and rejects any range past the end.
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("ETag", tt.etag)
+				if r.Header.Get("Range") != "" {
+					w.Header().Set("Content-Range", "bytes */10000")
+					w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+					return
+				}
+				_, _ = w.Write(content)
+			}))
+			defer server.Close()
+
+			dst := filepath.Join(t.TempDir(), "file.bin")
+			_ = os.WriteFile(dst+".part", tt.partial, 0o644)
+			_ = os.WriteFile(dst+".part.meta", []byte(`"v1"`), 0o644)
+
+			if err := NewRestClient(nil).Download(context.Background(), server.URL, dst, nil); err != nil {
+				t.Fatalf("Download failed: %v", err)
+			}
+			got, _ := os.ReadFile(dst)
+			if !bytes.Equal(got, content) {
+				t.Error("Downloaded content does not match")
+			}
+		})
+	}
+}
+
+func TestRestClient_Download_ChecksumMismatch(t *testing.T) {
+	var sent int64
+	server := newDownloadServer([]byte("payload"), `"v1"`, &sent, nil)
+	defer server.Close()
+
+	dst := filepath.Join(t.TempDir(), "file.bin")
+	err := NewRestClient(nil).Download(context.Background(), server.URL, dst, &DownloadOptions{
+		Checksum: hash.SHA256("other"),
+	})
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("Expected ErrChecksumMismatch, got %v", err)
+	}
+	for _, path := range []string{dst, dst + ".part"} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("Expected %s not to exist", path)
+		}
+	}
+}
+
+func TestRestClient_Download_Segmented(t *testing.T) {
+	content := bytes.Repeat([]byte("segmented-"), 1000)
+	var sent int64
+	var ranges int32
+	server := newDownloadServer(content, `"v1"`, &sent, &ranges)
+	defer server.Close()
+
+	dst := filepath.Join(t.TempDir(), "file.bin")
+	err := NewRestClient(nil).Download(context.Background(), server.URL, dst, &DownloadOptions{
+		Checksum:       hash.SHA256(string(content)),
+		Segments:       4,
+		MinSegmentSize: 1000,
+	})
+	if err != nil {
+		t.Fatalf("Download failed: %v", err)
+	}
+	if ranges != 4 {
+		t.Errorf("Expected 4 ranged requests, got %d", ranges)
+	}
+	got, _ := os.ReadFile(dst)
+	if !bytes.Equal(got, content) {
+		t.Error("Segmented content does not match")
+	}
+}
+
+func TestRestClient_Download_SegmentedStaleMeta(t *testing.T) {
+	content := bytes.Repeat([]byte("segmented-"), 1000)
+	var sent int64
+	server := newDownloadServer(content, `"v1"`, &sent, nil)
+	defer server.Close()
+
+	dst := filepath.Join(t.TempDir(), "file.bin")
+	_ = os.WriteFile(dst+".part", content[:4000], 0o644)
+	_ = os.WriteFile(dst+".part.meta", []byte(`"v1"`), 0o644)
+
+	// A crash while the segments are written must not leave the zero-filled
+	// file resumable.
+	var stale atomic.Bool
+	err := NewRestClient(nil).Download(context.Background(), server.URL, dst, &DownloadOptions{
+		Segments:       4,
+		MinSegmentSize: 1000,
+		Progress: func(done, total int64) {
+			if _, err := os.Stat(dst + ".part.meta"); err == nil {
+				stale.Store(true)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("Download failed: %v", err)
+	}
+	if stale.Load() {
+		t.Error("Expected the stale metadata to be removed before the segments are written")
+	}
+}
+
+func TestRestClient_Download_Error(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	dst := filepath.Join(t.TempDir(), "file.bin")
+	if err := NewRestClient(nil).Download(context.Background(), server.URL, dst, nil); err == nil {
+		t.Fatal("Expected an error for 404 response")
+	}
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		t.Error("Destination should not exist after a failed download")
+	}
+}
+
+func TestParseContentRange(t *testing.T) {
+	tests := []struct {
+		input            string
+		start, end, size int64
+		ok               bool
+	}{
+		{"bytes 0-99/1000", 0, 99, 1000, true},
+		{"bytes 100-199/*", 100, 199, -1, true},
+		{"bytes */1000", 0, 0, 1000, true},
+		{"items 0-1/2", 0, 0, 0, false},
+		{"bytes 0-x/10", 0, 0, 0, false},
+	}
+	for _, tt := range tests {
+		start, end, size, ok := parseContentRange(tt.input)
+		if ok != tt.ok || start != tt.start || end != tt.end || size != tt.size {
+			t.Errorf("parseContentRange(%q) = %d, %d, %d, %v", tt.input, start, end, size, ok)
+		}
+	}
+}
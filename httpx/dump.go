@@ -0,0 +1,365 @@
+package httpx
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go4x/goal/stringx"
+)
+
+// DefaultMaskedHeaders are the headers whose values ToCurl and DebugTransport hide
+// unless told otherwise.
+var DefaultMaskedHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Api-Key",
+	"X-Auth-Token",
+}
+
+// DefaultMaskedParams are the query parameters whose values ToCurl and
+// DebugTransport hide unless told otherwise.
+var DefaultMaskedParams = []string{
+	"access_token",
+	"refresh_token",
+	"id_token",
+	"token",
+	"api_key",
+	"apikey",
+	"client_secret",
+	"password",
+	"signature",
+	"sig",
+}
+
+// maskedValue replaces the values of masked headers.
+const maskedValue = "***"
+
+// ToCurl returns a curl command line equivalent to req. The values of the headers
+// and query parameters named in masked, DefaultMaskedHeaders and
+// DefaultMaskedParams if none are given, are replaced by "***" and the password
+// of the URL by "xxxxx". The request body is read through GetBody when possible,
+// and otherwise buffered and restored, so req can still be sent afterwards.
+//
+// Example:
+//
+//	req := httpx.MustNewRequest(http.MethodPost, "https://api.example.com/users",
+//		strings.NewReader(`{"name":"John"}`), httpx.WithContentType(httpx.ContentTypeApplicationJson))
+//	cmd, _ := httpx.ToCurl(req)
+//	// curl -X POST https://api.example.com/users -H 'Content-Type: application/json' --data-binary '{"name":"John"}'
+func ToCurl(req Request, masked ...string) (string, error) {
+	if len(masked) == 0 {
+		masked = defaultMasked()
+	}
+	hreq := req.GetRequest()
+	body, err := peekBody(hreq)
+	if err != nil {
+		return "", err
+	}
+
+	parts := []string{"curl"}
+	switch hreq.Method {
+	case http.MethodHead:
+		parts = append(parts, "-I")
+	case http.MethodGet, "":
+		// curl sends a body with POST unless told otherwise.
+		if len(body) > 0 {
+			parts = append(parts, "-X", http.MethodGet)
+		}
+	default:
+		parts = append(parts, "-X", stringx.ShellQuote(hreq.Method))
+	}
+	parts = append(parts, stringx.ShellQuote(redactURL(hreq.URL, masked)))
+	header := maskHeader(hreq.Header, masked)
+	for _, key := range sortedKeys(header) {
+		for _, value := range header[key] {
+			parts = append(parts, "-H", stringx.ShellQuote(key+": "+value))
+		}
+	}
+	if len(body) > 0 {
+		parts = append(parts, "--data-binary", stringx.ShellQuote(string(body)))
+	}
+	return strings.Join(parts, " "), nil
+}
+
+// DebugTransport is an http.RoundTripper that writes a dump of every request and
+// response to Output. Bodies longer than MaxBodySize are truncated in the dump,
+// and the values of MaskedHeaders are hidden. Responses are passed on unchanged:
+// their headers are dumped as soon as they arrive, and their body as the caller
+// reads it, once read to the end or closed, so streamed responses such as
+// server-sent events are not held back.
+type DebugTransport struct {
+	// Output receives the dumps, os.Stderr if nil.
+	Output io.Writer
+	// MaxBodySize is the number of body bytes dumped, 4 KiB if zero; negative
+	// values omit bodies.
+	MaxBodySize int
+	// MaskedHeaders are the headers and query parameters hidden in the dumps,
+	// DefaultMaskedHeaders and DefaultMaskedParams if nil.
+	MaskedHeaders []string
+	// Transport sends the requests, http.DefaultTransport if nil.
+	Transport http.RoundTripper
+
+	// mu keeps the dumps of concurrent requests from interleaving.
+	mu sync.Mutex
+}
+
+// NewDebugTransport creates a DebugTransport writing to output and sending
+// requests with next, http.DefaultTransport if nil.
+func NewDebugTransport(output io.Writer, next http.RoundTripper) *DebugTransport {
+	return &DebugTransport{Output: output, Transport: next}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *DebugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport := t.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	var dump bytes.Buffer
+	fmt.Fprintf(&dump, "> %s %s %s\n", req.Method, redactURL(req.URL, t.masked()), req.Proto)
+	fmt.Fprintf(&dump, "> Host: %s\n", req.Host)
+	t.writeHeader(&dump, "> ", req.Header)
+	if req.Body != nil && req.Body != http.NoBody && t.maxBodySize() >= 0 {
+		prefix, body, err := readPrefix(req.Body, t.maxBodySize())
+		if err != nil {
+			return nil, err
+		}
+		req = req.Clone(req.Context())
+		req.Body = body
+		t.writeBody(&dump, prefix, req.ContentLength)
+	}
+
+	start := time.Now()
+	resp, err := transport.RoundTrip(req)
+	elapsed := time.Since(start)
+	if err != nil {
+		fmt.Fprintf(&dump, "! %v (%v)\n\n", err, elapsed)
+		t.write(dump.Bytes())
+		return nil, err
+	}
+
+	fmt.Fprintf(&dump, "< %s %s (%v)\n", resp.Proto, resp.Status, elapsed)
+	t.writeHeader(&dump, "< ", resp.Header)
+	dump.WriteString("\n")
+	t.write(dump.Bytes())
+	// The body of a protocol switch is the connection itself, not to be wrapped.
+	if t.maxBodySize() >= 0 && resp.Body != nil && resp.Body != http.NoBody &&
+		resp.StatusCode != http.StatusSwitchingProtocols {
+		resp.Body = &dumpBody{
+			ReadCloser: resp.Body,
+			t:          t,
+			title:      req.Method + " " + redactURL(req.URL, t.masked()),
+			length:     resp.ContentLength,
+		}
+	}
+	return resp, nil
+}
+
+// WithDebug returns a copy of the client that dumps every request and response
+// to output, os.Stderr if nil. The original client is not modified.
+func (c *RestClient) WithDebug(output io.Writer) *RestClient {
+	return c.withTransport(func(next http.RoundTripper) http.RoundTripper {
+		return NewDebugTransport(output, next)
+	})
+}
+
+func (t *DebugTransport) maxBodySize() int {
+	if t.MaxBodySize == 0 {
+		return 4 << 10
+	}
+	return t.MaxBodySize
+}
+
+func (t *DebugTransport) masked() []string {
+	if t.MaskedHeaders == nil {
+		return defaultMasked()
+	}
+	return t.MaskedHeaders
+}
+
+func (t *DebugTransport) writeHeader(w io.Writer, prefix string, header http.Header) {
+	header = maskHeader(header, t.masked())
+	for _, key := range sortedKeys(header) {
+		for _, value := range header[key] {
+			fmt.Fprintf(w, "%s%s: %s\n", prefix, key, value)
+		}
+	}
+}
+
+// writeBody writes the dumped prefix of a body and notes how much was left out.
+// length is the Content-Length of the body, -1 if unknown.
+func (t *DebugTransport) writeBody(w *bytes.Buffer, prefix []byte, length int64) {
+	if len(prefix) == 0 {
+		return
+	}
+	w.WriteString("\n")
+	if len(prefix) <= t.maxBodySize() {
+		w.Write(prefix)
+		w.WriteString("\n")
+		return
+	}
+	w.Write(prefix[:t.maxBodySize()])
+	if length > 0 {
+		fmt.Fprintf(w, "\n... (%d more bytes)\n", length-int64(t.maxBodySize()))
+	} else {
+		w.WriteString("\n... (truncated)\n")
+	}
+}
+
+func (t *DebugTransport) write(p []byte) {
+	output := t.Output
+	if output == nil {
+		output = os.Stderr
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, _ = output.Write(p)
+}
+
+// dumpBody passes a response body through, keeping the first bytes read, and
+// dumps them once the body is read to the end or closed.
+type dumpBody struct {
+	io.ReadCloser
+	t      *DebugTransport
+	title  string
+	length int64 // -1 until known
+	prefix []byte
+	read   int64
+	once   sync.Once
+}
+
+func (b *dumpBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if keep := b.t.maxBodySize() + 1 - len(b.prefix); keep > 0 {
+		b.prefix = append(b.prefix, p[:min(n, keep)]...)
+	}
+	b.read += int64(n)
+	if err == io.EOF {
+		b.length = b.read
+		b.dump()
+	}
+	return n, err
+}
+
+func (b *dumpBody) Close() error {
+	b.dump()
+	return b.ReadCloser.Close()
+}
+
+func (b *dumpBody) dump() {
+	b.once.Do(func() {
+		if len(b.prefix) == 0 {
+			return
+		}
+		var dump bytes.Buffer
+		fmt.Fprintf(&dump, "< body of %s\n", b.title)
+		b.t.writeBody(&dump, b.prefix, b.length)
+		dump.WriteString("\n")
+		b.t.write(dump.Bytes())
+	})
+}
+
+// readPrefix reads up to n+1 bytes of body, so callers can tell whether it is
+// longer than n, and returns them together with a body replaying them followed
+// by the rest of the original body.
+func readPrefix(body io.ReadCloser, n int) ([]byte, io.ReadCloser, error) {
+	prefix, err := io.ReadAll(io.LimitReader(body, int64(n)+1))
+	if err != nil {
+		return nil, nil, err
+	}
+	return prefix, struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(prefix), body), body}, nil
+}
+
+// peekBody returns the body of req without consuming it.
+func peekBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = body.Close() }()
+		return io.ReadAll(body)
+	}
+	data, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	return data, nil
+}
+
+// defaultMasked returns the headers and query parameters masked by default.
+func defaultMasked() []string {
+	return slices.Concat(DefaultMaskedHeaders, DefaultMaskedParams)
+}
+
+// isMasked reports whether name is in masked, ignoring case.
+func isMasked(name string, masked []string) bool {
+	return slices.ContainsFunc(masked, func(m string) bool { return strings.EqualFold(m, name) })
+}
+
+// maskHeader returns a copy of header with the values of the masked headers replaced.
+func maskHeader(header http.Header, maskedHeaders []string) http.Header {
+	clone := header.Clone()
+	for key, values := range clone {
+		if isMasked(key, maskedHeaders) {
+			for i := range values {
+				values[i] = maskedValue
+			}
+		}
+	}
+	return clone
+}
+
+// redactURL returns u with its password and the values of the masked query
+// parameters hidden. The other parameters are kept as written.
+func redactURL(u *url.URL, masked []string) string {
+	if u.RawQuery == "" {
+		return u.Redacted()
+	}
+	pairs := strings.Split(u.RawQuery, "&")
+	for i, pair := range pairs {
+		key, _, _ := strings.Cut(pair, "=")
+		name, err := url.QueryUnescape(key)
+		if err != nil {
+			name = key
+		}
+		if isMasked(name, masked) {
+			pairs[i] = key + "=" + maskedValue
+		}
+	}
+	redacted := *u
+	redacted.RawQuery = strings.Join(pairs, "&")
+	return redacted.Redacted()
+}
+
+func sortedKeys(header http.Header) []string {
+	keys := make([]string, 0, len(header))
+	for key := range header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
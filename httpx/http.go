@@ -22,6 +22,7 @@
 package httpx
 
 import (
+	"context"
 	"io"
 	"net/url"
 )
@@ -463,3 +464,19 @@ func DeleteJson(url string, body io.Reader) (*Response, error) {
 func DeleteForm(url string, body io.Reader) (*Response, error) {
 	return DefaultClient.DeleteForm(url, body)
 }
+
+// Download fetches url into dstPath using the default HTTP client.
+// See RestClient.Download for resume, verification and segmentation behaviour.
+// The default client's 60-second timeout applies to the whole transfer, so use a
+// dedicated RestClient for large files.
+//
+// Example:
+//
+//	err := httpx.Download(ctx, "https://example.com/app.tar.gz", "/tmp/app.tar.gz",
+//		&httpx.DownloadOptions{Checksum: expectedSHA256})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+func Download(ctx context.Context, url string, dstPath string, opts *DownloadOptions) error {
+	return DefaultClient.Download(ctx, url, dstPath, opts)
+}
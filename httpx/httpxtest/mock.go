@@ -0,0 +1,400 @@
+// Package httpxtest provides utilities for testing code built on httpx without
+// starting real HTTP servers.
+//
+// MockTransport is a programmable http.RoundTripper: routes match requests on
+// method, path, query, headers and body and return canned responses, and every
+// call is recorded for assertions. Recorder captures real interactions into a
+// cassette file and replays them later, redacting sensitive headers.
+//
+// Example:
+//
+//	mock := httpxtest.NewMockTransport()
+//	mock.On(http.MethodGet, "/users/*").
+//		WithHeader("Authorization", "Bearer token").
+//		ReplyJSON(http.StatusOK, map[string]string{"name": "John"})
+//
+//	client := mock.Client()
+//	resp, err := client.Get("https://api.example.com/users/42", nil)
+//
+//	mock.AssertCalled(t, http.MethodGet, "/users/42")
+//	mock.AssertExpectations(t)
+package httpxtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go4x/goal/httpx"
+)
+
+// Call is a request received by a MockTransport.
+type Call struct {
+	// Request is the received request; its body has been consumed.
+	Request *http.Request
+	// Body is the request body.
+	Body []byte
+	// Route is the route that answered the request, nil if none matched.
+	Route *Route
+}
+
+// MockTransport is an http.RoundTripper answering requests from registered routes.
+// Routes are matched in registration order; a route limited with Times stops
+// matching once exhausted. Requests matching no route fail with an error.
+type MockTransport struct {
+	mu     sync.Mutex
+	routes []*Route
+	calls  []*Call
+}
+
+// NewMockTransport creates an empty MockTransport.
+func NewMockTransport() *MockTransport {
+	return &MockTransport{}
+}
+
+// Client returns a RestClient sending its requests through the transport.
+func (m *MockTransport) Client() *httpx.RestClient {
+	return httpx.NewRestClient(&http.Client{Transport: m})
+}
+
+// On registers a route for method and path. Use an empty method to match any
+// method; path may contain path.Match wildcards such as "/users/*".
+func (m *MockTransport) On(method, pattern string) *Route {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r := &Route{method: method, path: pattern, status: http.StatusOK, header: make(http.Header)}
+	m.routes = append(m.routes, r)
+	return r
+}
+
+// RoundTrip implements http.RoundTripper.
+func (m *MockTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	m.mu.Lock()
+	call := &Call{Request: req, Body: body}
+	m.calls = append(m.calls, call)
+	for _, r := range m.routes {
+		if r.exhausted() || !r.matches(req, body) {
+			continue
+		}
+		r.calls++
+		call.Route = r
+		break
+	}
+	m.mu.Unlock()
+
+	if call.Route == nil {
+		return nil, fmt.Errorf("httpxtest: no route matches %s %s", req.Method, req.URL)
+	}
+	return call.Route.respond(req)
+}
+
+// Calls returns all requests received so far, in order.
+func (m *MockTransport) Calls() []*Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*Call(nil), m.calls...)
+}
+
+// CallCount returns the number of received requests with the given method and
+// path, where path may contain wildcards like On.
+func (m *MockTransport) CallCount(method, pattern string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := 0
+	for _, c := range m.calls {
+		if matchMethodPath(method, pattern, c.Request) {
+			n++
+		}
+	}
+	return n
+}
+
+// Reset removes all routes and recorded calls.
+func (m *MockTransport) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.routes, m.calls = nil, nil
+}
+
+// AssertCalled fails the test if no request with method and path was received.
+func (m *MockTransport) AssertCalled(t testing.TB, method, pattern string) bool {
+	t.Helper()
+	if m.CallCount(method, pattern) == 0 {
+		t.Errorf("httpxtest: expected a call to %s %s, got none", method, pattern)
+		return false
+	}
+	return true
+}
+
+// AssertNotCalled fails the test if a request with method and path was received.
+func (m *MockTransport) AssertNotCalled(t testing.TB, method, pattern string) bool {
+	t.Helper()
+	if n := m.CallCount(method, pattern); n != 0 {
+		t.Errorf("httpxtest: expected no call to %s %s, got %d", method, pattern, n)
+		return false
+	}
+	return true
+}
+
+// AssertNumberOfCalls fails the test unless exactly n requests with method and
+// path were received.
+func (m *MockTransport) AssertNumberOfCalls(t testing.TB, method, pattern string, n int) bool {
+	t.Helper()
+	if got := m.CallCount(method, pattern); got != n {
+		t.Errorf("httpxtest: expected %d calls to %s %s, got %d", n, method, pattern, got)
+		return false
+	}
+	return true
+}
+
+// AssertExpectations fails the test if a route limited with Times or Once was not
+// called exactly that many times, or if a request matched no route.
+func (m *MockTransport) AssertExpectations(t testing.TB) bool {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ok := true
+	for _, r := range m.routes {
+		if r.times > 0 && r.calls != r.times {
+			t.Errorf("httpxtest: expected %d calls to %s, got %d", r.times, r, r.calls)
+			ok = false
+		}
+	}
+	for _, c := range m.calls {
+		if c.Route == nil {
+			t.Errorf("httpxtest: unexpected call to %s %s", c.Request.Method, c.Request.URL)
+			ok = false
+		}
+	}
+	return ok
+}
+
+// Route matches requests and describes the canned response returned for them.
+// Its methods return the route itself so they can be chained.
+type Route struct {
+	method   string
+	path     string
+	query    map[string]string
+	headers  map[string]string
+	matchers []func(*http.Request, []byte) bool
+
+	status int
+	header http.Header
+	body   []byte
+	err    error
+	delay  time.Duration
+	times  int
+	calls  int
+}
+
+// WithQuery requires the query parameter key to equal value.
+func (r *Route) WithQuery(key, value string) *Route {
+	if r.query == nil {
+		r.query = make(map[string]string)
+	}
+	r.query[key] = value
+	return r
+}
+
+// WithHeader requires the request header key to equal value.
+func (r *Route) WithHeader(key, value string) *Route {
+	if r.headers == nil {
+		r.headers = make(map[string]string)
+	}
+	r.headers[key] = value
+	return r
+}
+
+// WithBody requires the request body to equal body.
+func (r *Route) WithBody(body string) *Route {
+	return r.Match(func(_ *http.Request, b []byte) bool { return string(b) == body })
+}
+
+// WithBodyContains requires the request body to contain substr.
+func (r *Route) WithBodyContains(substr string) *Route {
+	return r.Match(func(_ *http.Request, b []byte) bool { return bytes.Contains(b, []byte(substr)) })
+}
+
+// WithJSONBody requires the request body to be JSON equivalent to v, ignoring
+// formatting and key order.
+func (r *Route) WithJSONBody(v any) *Route {
+	want, err := normalizeJSON(v)
+	return r.Match(func(_ *http.Request, b []byte) bool {
+		var got any
+		if err != nil || json.Unmarshal(b, &got) != nil {
+			return false
+		}
+		return reflect.DeepEqual(got, want)
+	})
+}
+
+// Match adds a custom matcher receiving the request and its body.
+func (r *Route) Match(fn func(req *http.Request, body []byte) bool) *Route {
+	r.matchers = append(r.matchers, fn)
+	return r
+}
+
+// Reply sets the response status code and body.
+func (r *Route) Reply(status int, body string) *Route {
+	r.status = status
+	r.body = []byte(body)
+	return r
+}
+
+// ReplyJSON sets the response status code and a JSON body encoded from v.
+// It panics if v cannot be encoded.
+func (r *Route) ReplyJSON(status int, v any) *Route {
+	body, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("httpxtest: cannot encode reply: %v", err))
+	}
+	r.status = status
+	r.body = body
+	r.header.Set("Content-Type", httpx.ContentTypeApplicationJson)
+	return r
+}
+
+// ReplyHeader adds a response header.
+func (r *Route) ReplyHeader(key, value string) *Route {
+	r.header.Add(key, value)
+	return r
+}
+
+// ReplyError makes matching requests fail with err instead of returning a response.
+func (r *Route) ReplyError(err error) *Route {
+	r.err = err
+	return r
+}
+
+// Delay waits d before responding, or until the request context is done.
+func (r *Route) Delay(d time.Duration) *Route {
+	r.delay = d
+	return r
+}
+
+// Times limits the route to n matches; AssertExpectations checks it was
+// called exactly n times.
+func (r *Route) Times(n int) *Route {
+	r.times = n
+	return r
+}
+
+// Once is shorthand for Times(1).
+func (r *Route) Once() *Route {
+	return r.Times(1)
+}
+
+// String describes the route for failure messages.
+func (r *Route) String() string {
+	method := r.method
+	if method == "" {
+		method = "*"
+	}
+	return method + " " + r.path
+}
+
+// exhausted reports whether the route reached its Times limit.
+func (r *Route) exhausted() bool {
+	return r.times > 0 && r.calls >= r.times
+}
+
+// matches reports whether req with body satisfies every condition of the route.
+func (r *Route) matches(req *http.Request, body []byte) bool {
+	if !matchMethodPath(r.method, r.path, req) {
+		return false
+	}
+	query := req.URL.Query()
+	for k, v := range r.query {
+		if query.Get(k) != v {
+			return false
+		}
+	}
+	for k, v := range r.headers {
+		if req.Header.Get(k) != v {
+			return false
+		}
+	}
+	for _, fn := range r.matchers {
+		if !fn(req, body) {
+			return false
+		}
+	}
+	return true
+}
+
+// respond builds the canned response for req.
+func (r *Route) respond(req *http.Request) (*http.Response, error) {
+	if r.delay > 0 {
+		timer := time.NewTimer(r.delay)
+		defer timer.Stop()
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.status, http.StatusText(r.status)),
+		StatusCode:    r.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        r.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(r.body)),
+		ContentLength: int64(len(r.body)),
+		Request:       req,
+	}, nil
+}
+
+// matchMethodPath reports whether req has the method (empty matches any) and a
+// path matching pattern.
+func matchMethodPath(method, pattern string, req *http.Request) bool {
+	if method != "" && !strings.EqualFold(method, req.Method) {
+		return false
+	}
+	if pattern == "" || pattern == req.URL.Path {
+		return true
+	}
+	ok, err := path.Match(pattern, req.URL.Path)
+	return err == nil && ok
+}
+
+// normalizeJSON converts v into the generic form produced by json.Unmarshal.
+func normalizeJSON(v any) (any, error) {
+	var data []byte
+	switch b := v.(type) {
+	case string:
+		data = []byte(b)
+	case []byte:
+		data = b
+	default:
+		var err error
+		if data, err = json.Marshal(v); err != nil {
+			return nil, err
+		}
+	}
+	var out any
+	err := json.Unmarshal(data, &out)
+	return out, err
+}
@@ -0,0 +1,136 @@
+package httpxtest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMockTransport_Routes(t *testing.T) {
+	mock := NewMockTransport()
+	mock.On(http.MethodGet, "/users/*").
+		WithQuery("expand", "true").
+		WithHeader("Authorization", "Bearer token").
+		ReplyJSON(http.StatusOK, map[string]string{"name": "John"}).
+		ReplyHeader("X-Request-Id", "42")
+	mock.On(http.MethodPost, "/users").
+		WithJSONBody(`{"name": "Jane", "age": 30}`).
+		Reply(http.StatusCreated, "created").
+		Once()
+
+	client := mock.Client()
+	if _, err := client.Get("https://api.example.com/users/7?expand=true", nil); err == nil {
+		t.Fatal("Expected an error without the Authorization header")
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "https://api.example.com/users/7?expand=true", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.Header.Get("X-Request-Id") != "42" || resp.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("Unexpected reply headers: %v", resp.Header)
+	}
+
+	created, err := client.PostJson("https://api.example.com/users", strings.NewReader(`{"age":30,"name":"Jane"}`))
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	if body, _ := created.String(); created.StatusCode != http.StatusCreated || body != "created" {
+		t.Errorf("Expected 201 created, got %d %q", created.StatusCode, body)
+	}
+	if _, err := client.PostJson("https://api.example.com/users", strings.NewReader(`{"age":30,"name":"Jane"}`)); err == nil {
+		t.Error("Route limited with Once should not match twice")
+	}
+
+	mock.AssertCalled(t, http.MethodGet, "/users/7")
+	mock.AssertNumberOfCalls(t, http.MethodPost, "/users", 2)
+	mock.AssertNotCalled(t, http.MethodDelete, "/users/*")
+	calls := mock.Calls()
+	if len(calls) != 4 {
+		t.Fatalf("Expected 4 recorded calls, got %d", len(calls))
+	}
+	if string(calls[2].Body) != `{"age":30,"name":"Jane"}` {
+		t.Errorf("Expected recorded body, got %q", calls[2].Body)
+	}
+}
+
+func TestMockTransport_JSONReply(t *testing.T) {
+	mock := NewMockTransport()
+	mock.On("", "/items").ReplyJSON(http.StatusOK, []int{1, 2, 3})
+
+	resp, err := mock.Client().Get("http://localhost/items", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var items []int
+	if err := resp.JSON(&items); err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 3 || items[2] != 3 {
+		t.Errorf("Unexpected items: %v", items)
+	}
+}
+
+func TestMockTransport_ErrorAndDelay(t *testing.T) {
+	mock := NewMockTransport()
+	boom := errors.New("connection reset")
+	mock.On(http.MethodGet, "/fail").ReplyError(boom)
+	mock.On(http.MethodGet, "/slow").Delay(50 * time.Millisecond)
+
+	client := mock.Client()
+	if _, err := client.Get("http://localhost/fail", nil); !errors.Is(err, boom) {
+		t.Errorf("Expected %v, got %v", boom, err)
+	}
+
+	start := time.Now()
+	if _, err := client.Get("http://localhost/slow", nil); err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(start) < 50*time.Millisecond {
+		t.Error("Delay should postpone the response")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost/slow", nil)
+	if _, err := client.Do(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+}
+
+func TestMockTransport_AssertExpectations(t *testing.T) {
+	mock := NewMockTransport()
+	mock.On(http.MethodGet, "/once").Once()
+	_, _ = mock.Client().Get("http://localhost/unknown", nil)
+
+	ft := &fakeTB{TB: t}
+	if mock.AssertExpectations(ft) {
+		t.Error("AssertExpectations should fail")
+	}
+	if len(ft.errors) != 2 {
+		t.Errorf("Expected 2 failures, got %v", ft.errors)
+	}
+
+	mock.Reset()
+	if len(mock.Calls()) != 0 || !mock.AssertExpectations(t) {
+		t.Error("Reset should clear routes and calls")
+	}
+}
+
+// fakeTB records failures instead of failing the test.
+type fakeTB struct {
+	testing.TB
+	errors []string
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Errorf(format string, args ...any) {
+	f.errors = append(f.errors, format)
+}
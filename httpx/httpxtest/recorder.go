@@ -0,0 +1,349 @@
+package httpxtest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/go4x/goal/httpx"
+)
+
+// Mode selects how a Recorder handles requests.
+type Mode int
+
+const (
+	// ModeReplay answers requests from the cassette only; unknown requests fail.
+	ModeReplay Mode = iota
+	// ModeRecord sends every request to the real transport and records it,
+	// replacing the previous cassette content.
+	ModeRecord
+	// ModeReplayOrRecord replays known requests and records unknown ones.
+	ModeReplayOrRecord
+)
+
+// Redacted replaces the values of redacted headers in saved cassettes.
+const Redacted = "REDACTED"
+
+// EncodingBase64 is the BodyEncoding of bodies saved as base64 because they are
+// not valid UTF-8, such as images or compressed data.
+const EncodingBase64 = "base64"
+
+// ErrInteractionNotFound is returned in replay mode for requests missing from
+// the cassette.
+var ErrInteractionNotFound = errors.New("httpxtest: interaction not found in cassette")
+
+// Interaction is one recorded request and its response.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is the request part of an Interaction.
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+	// BodyEncoding is EncodingBase64 if Body is base64, empty if it is text.
+	BodyEncoding string `json:"body_encoding,omitempty"`
+}
+
+// BodyBytes returns the body, decoded according to BodyEncoding.
+func (rr RecordedRequest) BodyBytes() ([]byte, error) {
+	return decodeBody(rr.Body, rr.BodyEncoding)
+}
+
+// RecordedResponse is the response part of an Interaction.
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	// BodyEncoding is EncodingBase64 if Body is base64, empty if it is text.
+	BodyEncoding string `json:"body_encoding,omitempty"`
+}
+
+// BodyBytes returns the body, decoded according to BodyEncoding.
+func (rr RecordedResponse) BodyBytes() ([]byte, error) {
+	return decodeBody(rr.Body, rr.BodyEncoding)
+}
+
+// Recorder is an http.RoundTripper that records real HTTP interactions into a
+// cassette file and replays them in later runs.
+//
+// Requests are matched on method, URL and body. Identical requests are replayed
+// in the order they were recorded. Headers listed with WithRedactedHeaders are
+// masked before the cassette is written, and bodies are passed through the
+// function set with WithBodyRedactor, so credentials are not committed. Bodies
+// that are not valid UTF-8 are saved as base64.
+//
+// Example:
+//
+//	rec, err := httpxtest.NewRecorder("testdata/users.json", httpxtest.ModeReplayOrRecord,
+//		httpxtest.WithRedactedHeaders("Authorization"))
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	defer rec.Stop()
+//	client := rec.Client()
+type Recorder struct {
+	mu           sync.Mutex
+	path         string
+	mode         Mode
+	transport    http.RoundTripper
+	redact       []string
+	redactBody   func(body []byte, header http.Header) []byte
+	interactions []*Interaction
+	used         []bool
+	dirty        bool
+}
+
+// RecorderOption configures a Recorder.
+type RecorderOption func(*Recorder)
+
+// WithRedactedHeaders masks the given request and response headers in the cassette.
+func WithRedactedHeaders(names ...string) RecorderOption {
+	return func(r *Recorder) {
+		r.redact = append(r.redact, names...)
+	}
+}
+
+// WithBodyRedactor sets a function rewriting request and response bodies before
+// they are saved, given the body and its unredacted headers. Request bodies are
+// also rewritten before being matched on replay, so redact must be
+// deterministic.
+//
+// Example:
+//
+//	token := regexp.MustCompile(`"access_token":"[^"]*"`)
+//	httpxtest.WithBodyRedactor(func(body []byte, _ http.Header) []byte {
+//		return token.ReplaceAll(body, []byte(`"access_token":"REDACTED"`))
+//	})
+func WithBodyRedactor(redact func(body []byte, header http.Header) []byte) RecorderOption {
+	return func(r *Recorder) {
+		r.redactBody = redact
+	}
+}
+
+// WithTransport sets the transport used to perform real requests,
+// http.DefaultTransport by default.
+func WithTransport(transport http.RoundTripper) RecorderOption {
+	return func(r *Recorder) {
+		r.transport = transport
+	}
+}
+
+// NewRecorder creates a Recorder backed by the cassette at path. The cassette is
+// loaded unless mode is ModeRecord; in ModeReplay it must exist.
+func NewRecorder(path string, mode Mode, options ...RecorderOption) (*Recorder, error) {
+	r := &Recorder{path: path, mode: mode, transport: http.DefaultTransport}
+	for _, option := range options {
+		option(r)
+	}
+	if mode == ModeRecord {
+		return r, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if mode == ModeReplayOrRecord && errors.Is(err, os.ErrNotExist) {
+			return r, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &r.interactions); err != nil {
+		return nil, fmt.Errorf("httpxtest: invalid cassette %s: %w", path, err)
+	}
+	for i, in := range r.interactions {
+		_, reqErr := in.Request.BodyBytes()
+		_, respErr := in.Response.BodyBytes()
+		if err := errors.Join(reqErr, respErr); err != nil {
+			return nil, fmt.Errorf("httpxtest: invalid cassette %s: interaction %d: %w", path, i, err)
+		}
+	}
+	r.used = make([]bool, len(r.interactions))
+	return r, nil
+}
+
+// Client returns a RestClient sending its requests through the recorder.
+func (r *Recorder) Client() *httpx.RestClient {
+	return httpx.NewRestClient(&http.Client{Transport: r})
+}
+
+// Interactions returns the interactions known to the recorder.
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]Interaction, len(r.interactions))
+	for i, in := range r.interactions {
+		out[i] = *in
+	}
+	return out
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	savedBody := r.redactedBody(body, req.Header)
+	if r.mode != ModeRecord {
+		if in := r.find(req, savedBody); in != nil {
+			return in.Response.toResponse(req), nil
+		}
+		if r.mode == ModeReplay {
+			return nil, fmt.Errorf("%w: %s %s", ErrInteractionNotFound, req.Method, req.URL)
+		}
+	}
+
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	reqBody, reqEncoding := encodeBody(savedBody)
+	savedRespBody, respEncoding := encodeBody(r.redactedBody(respBody, resp.Header))
+	r.mu.Lock()
+	r.interactions = append(r.interactions, &Interaction{
+		Request: RecordedRequest{
+			Method:       req.Method,
+			URL:          req.URL.String(),
+			Header:       r.redacted(req.Header),
+			Body:         reqBody,
+			BodyEncoding: reqEncoding,
+		},
+		Response: RecordedResponse{
+			StatusCode:   resp.StatusCode,
+			Header:       r.redacted(resp.Header),
+			Body:         savedRespBody,
+			BodyEncoding: respEncoding,
+		},
+	})
+	r.used = append(r.used, true)
+	r.dirty = true
+	r.mu.Unlock()
+	return resp, nil
+}
+
+// Stop writes the cassette if new interactions were recorded.
+func (r *Recorder) Stop() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.dirty {
+		return nil
+	}
+	data, err := json.MarshalIndent(r.interactions, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(r.path, data, 0o644); err != nil {
+		return err
+	}
+	r.dirty = false
+	return nil
+}
+
+// find returns the first unused recorded interaction matching req and its
+// redacted body.
+func (r *Recorder) find(req *http.Request, body []byte) *Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	url := req.URL.String()
+	for i, in := range r.interactions {
+		if r.used[i] || in.Request.Method != req.Method || in.Request.URL != url {
+			continue
+		}
+		if recorded, err := in.Request.BodyBytes(); err != nil || !bytes.Equal(recorded, body) {
+			continue
+		}
+		r.used[i] = true
+		return in
+	}
+	return nil
+}
+
+// redacted returns a copy of header with redacted values masked.
+func (r *Recorder) redacted(header http.Header) http.Header {
+	out := header.Clone()
+	for _, name := range r.redact {
+		if values := out.Values(name); len(values) > 0 {
+			out.Del(name)
+			for range values {
+				out.Add(name, Redacted)
+			}
+		}
+	}
+	return out
+}
+
+// redactedBody returns body as saved in the cassette.
+func (r *Recorder) redactedBody(body []byte, header http.Header) []byte {
+	if r.redactBody == nil || len(body) == 0 {
+		return body
+	}
+	return r.redactBody(bytes.Clone(body), header)
+}
+
+// encodeBody returns body as saved in a cassette and its encoding.
+func encodeBody(body []byte) (string, string) {
+	if utf8.Valid(body) {
+		return string(body), ""
+	}
+	return base64.StdEncoding.EncodeToString(body), EncodingBase64
+}
+
+// decodeBody reverses encodeBody.
+func decodeBody(body, encoding string) ([]byte, error) {
+	switch encoding {
+	case "":
+		return []byte(body), nil
+	case EncodingBase64:
+		return base64.StdEncoding.DecodeString(body)
+	default:
+		return nil, fmt.Errorf("unknown body encoding %q", encoding)
+	}
+}
+
+// toResponse builds an http.Response for req from the recorded response, whose
+// body was checked when the cassette was loaded.
+func (rr RecordedResponse) toResponse(req *http.Request) *http.Response {
+	body, _ := rr.BodyBytes()
+	header := rr.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", rr.StatusCode, http.StatusText(rr.StatusCode)),
+		StatusCode:    rr.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,173 @@
+package httpxtest
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRecorder_RecordAndReplay(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&hits, 1)
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Set-Cookie", "session=secret")
+		_, _ = io.WriteString(w, r.Method+" "+string(body)+" "+string(rune('0'+n)))
+	}))
+	defer server.Close()
+
+	cassette := filepath.Join(t.TempDir(), "cassettes", "api.json")
+	rec, err := NewRecorder(cassette, ModeRecord, WithRedactedHeaders("Authorization", "Set-Cookie"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := rec.Client()
+	for _, body := range []string{"a", "a", "b"} {
+		req, _ := http.NewRequest(http.MethodPost, server.URL+"/items", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+	}
+	if err := rec.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(cassette)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret") {
+		t.Error("Cassette should not contain redacted values")
+	}
+
+	replay, err := NewRecorder(cassette, ModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client = replay.Client()
+	for _, want := range []string{"POST a 1", "POST a 2", "POST b 3"} {
+		body := want[5:6]
+		resp, err := client.Post(server.URL+"/items", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := resp.String(); got != want {
+			t.Errorf("Expected replayed body %q, got %q", want, got)
+		}
+	}
+	if _, err := client.Post(server.URL+"/items", strings.NewReader("a")); !errors.Is(err, ErrInteractionNotFound) {
+		t.Errorf("Expected ErrInteractionNotFound after cassette is used up, got %v", err)
+	}
+	if hits != 3 {
+		t.Errorf("Replay should not reach the server, got %d hits", hits)
+	}
+}
+
+func TestRecorder_ReplayOrRecord(t *testing.T) {
+	mock := NewMockTransport()
+	mock.On(http.MethodGet, "/ping").Reply(http.StatusOK, "pong")
+
+	cassette := filepath.Join(t.TempDir(), "ping.json")
+	if _, err := NewRecorder(cassette, ModeReplay); err == nil {
+		t.Fatal("ModeReplay should require an existing cassette")
+	}
+
+	for i := 0; i < 2; i++ {
+		rec, err := NewRecorder(cassette, ModeReplayOrRecord, WithTransport(mock))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := rec.Client().Get("http://localhost/ping", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if body, _ := resp.String(); body != "pong" {
+			t.Errorf("Expected pong, got %q", body)
+		}
+		if err := rec.Stop(); err != nil {
+			t.Fatal(err)
+		}
+		if n := len(rec.Interactions()); n != 1 {
+			t.Errorf("Expected 1 interaction, got %d", n)
+		}
+	}
+	mock.AssertNumberOfCalls(t, http.MethodGet, "/ping", 1)
+}
+
+func TestRecorder_BinaryAndRedactedBodies(t *testing.T) {
+	image := []byte{0x89, 'P', 'N', 'G', 0x00, 0xff, 0xfe}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			_, _ = io.WriteString(w, `{"access_token":"live-token"}`)
+			return
+		}
+		_, _ = w.Write(image)
+	}))
+	defer server.Close()
+
+	token := regexp.MustCompile(`"(access_token|password)":"[^"]*"`)
+	redactor := WithBodyRedactor(func(body []byte, _ http.Header) []byte {
+		return token.ReplaceAll(body, []byte(`"$1":"REDACTED"`))
+	})
+	send := func(rec *Recorder) (login, png []byte) {
+		t.Helper()
+		client := rec.Client()
+		req, _ := http.NewRequest(http.MethodPost, server.URL+"/token", strings.NewReader(`{"password":"hunter2"}`))
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		login, _ = io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		req, _ = http.NewRequest(http.MethodGet, server.URL+"/logo.png", nil)
+		resp, err = client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		png, _ = io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		return login, png
+	}
+
+	cassette := filepath.Join(t.TempDir(), "binary.json")
+	rec, err := NewRecorder(cassette, ModeRecord, redactor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if login, _ := send(rec); string(login) != `{"access_token":"live-token"}` {
+		t.Errorf("The live response should not be redacted, got %s", login)
+	}
+	if err := rec.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(cassette)
+	if strings.Contains(string(data), "hunter2") || strings.Contains(string(data), "live-token") {
+		t.Errorf("Bodies should be redacted in the cassette:\n%s", data)
+	}
+	if !strings.Contains(string(data), `"body_encoding": "base64"`) {
+		t.Errorf("Binary bodies should be saved as base64:\n%s", data)
+	}
+
+	rec, err = NewRecorder(cassette, ModeReplay, redactor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	login, png := send(rec)
+	if string(login) != `{"access_token":"REDACTED"}` {
+		t.Errorf("Expected the redacted response, got %s", login)
+	}
+	if !bytes.Equal(png, image) {
+		t.Errorf("Binary body corrupted on replay: %x", png)
+	}
+}
@@ -0,0 +1,336 @@
+package httpx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go4x/goal/limiter"
+)
+
+// ErrPageLoop is yielded by Paginate when the pagination leads back to a page
+// already fetched, such as a server returning the same cursor again.
+var ErrPageLoop = errors.New("httpx: pagination loop")
+
+// Page is a fetched page of a paginated API, passed to Pagination.Next.
+type Page struct {
+	// URL is the address the page was fetched from.
+	URL *url.URL
+	// Header is the response header.
+	Header http.Header
+	// Body is the raw response body.
+	Body []byte
+	// Items is the number of items the page contained.
+	Items int
+}
+
+// Pagination tells Paginate how to get from one page to the next.
+type Pagination interface {
+	// Next returns the URL of the page after page, or nil if page is the last one.
+	Next(page *Page) (*url.URL, error)
+}
+
+// pageStarter is implemented by pagination strategies that need to adjust the
+// URL of the first page.
+type pageStarter interface {
+	First(u *url.URL) *url.URL
+}
+
+// LinkPagination follows the rel="next" link of the Link response header (RFC 8288),
+// as used by the GitHub and GitLab APIs.
+type LinkPagination struct{}
+
+// Next implements Pagination.
+func (LinkPagination) Next(page *Page) (*url.URL, error) {
+	next, ok := parseLinkHeader(page.Header.Values("Link"))["next"]
+	if !ok {
+		return nil, nil
+	}
+	u, err := page.URL.Parse(next)
+	if err != nil {
+		return nil, fmt.Errorf("httpx: invalid next link %q: %w", next, err)
+	}
+	return u, nil
+}
+
+// CursorPagination reads the cursor of the next page from a field of the JSON body
+// and sends it back in a query parameter. Pagination stops when the field is
+// missing, null or empty.
+type CursorPagination struct {
+	// Field is the dot-separated path of the cursor in the body, e.g. "meta.next_cursor".
+	Field string
+	// Param is the query parameter carrying the cursor, e.g. "cursor".
+	Param string
+}
+
+// Next implements Pagination.
+func (p CursorPagination) Next(page *Page) (*url.URL, error) {
+	raw, err := jsonPath(page.Body, p.Field)
+	if err != nil || raw == nil {
+		return nil, err
+	}
+	var cursor string
+	if err := json.Unmarshal(raw, &cursor); err != nil {
+		// Numeric cursors are sent as they appear in the body.
+		cursor = strings.TrimSpace(string(raw))
+	}
+	if cursor == "" || cursor == "null" || cursor == "false" {
+		return nil, nil
+	}
+	return withQuery(page.URL, p.Param, cursor), nil
+}
+
+// OffsetPagination requests pages of Limit items through offset and limit query
+// parameters. Pagination stops at the first page with fewer than Limit items.
+type OffsetPagination struct {
+	// OffsetParam is the offset query parameter, "offset" if empty.
+	OffsetParam string
+	// LimitParam is the page size query parameter, "limit" if empty.
+	LimitParam string
+	// Limit is the page size, 100 if zero.
+	Limit int
+}
+
+// First sets the offset and limit of the first page.
+func (p OffsetPagination) First(u *url.URL) *url.URL {
+	u = withQuery(u, p.limitParam(), strconv.Itoa(p.limit()))
+	if u.Query().Get(p.offsetParam()) == "" {
+		u = withQuery(u, p.offsetParam(), "0")
+	}
+	return u
+}
+
+// Next implements Pagination.
+func (p OffsetPagination) Next(page *Page) (*url.URL, error) {
+	if page.Items < p.limit() {
+		return nil, nil
+	}
+	offset, err := strconv.Atoi(page.URL.Query().Get(p.offsetParam()))
+	if err != nil {
+		return nil, fmt.Errorf("httpx: invalid %s parameter: %w", p.offsetParam(), err)
+	}
+	return withQuery(page.URL, p.offsetParam(), strconv.Itoa(offset+page.Items)), nil
+}
+
+func (p OffsetPagination) offsetParam() string {
+	if p.OffsetParam == "" {
+		return "offset"
+	}
+	return p.OffsetParam
+}
+
+func (p OffsetPagination) limitParam() string {
+	if p.LimitParam == "" {
+		return "limit"
+	}
+	return p.LimitParam
+}
+
+func (p OffsetPagination) limit() int {
+	if p.Limit <= 0 {
+		return 100
+	}
+	return p.Limit
+}
+
+// PageOptions configures Paginate.
+type PageOptions struct {
+	// Pagination is the paging strategy, LinkPagination if nil.
+	Pagination Pagination
+	// ItemsField is the dot-separated path of the item array in the JSON body,
+	// e.g. "data". If empty, the body itself must be an array.
+	ItemsField string
+	// MaxPages stops after this many pages, no limit if zero.
+	MaxPages int
+	// Limiter, if set, is taken once before each page request. It must be started.
+	Limiter limiter.Limiter
+	// RequestOptions are applied to every page request.
+	RequestOptions []RequestOption
+}
+
+// Paginate returns an iterator over the items of all pages of a paginated JSON API,
+// starting at rawURL. Pages are fetched lazily as the iteration proceeds and each item
+// is decoded into a T.
+//
+// A failed page request, a non-2xx status or a decoding error is yielded once and
+// ends the iteration, as does cancelling ctx. So does a next page already
+// fetched, with an error wrapping ErrPageLoop, rather than paginating forever. A
+// nil client uses DefaultClient.
+//
+// Example:
+//
+//	users := httpx.Paginate[User](ctx, client, "https://api.example.com/users", httpx.PageOptions{
+//		Pagination: httpx.CursorPagination{Field: "meta.next_cursor", Param: "cursor"},
+//		ItemsField: "data",
+//	})
+//	for user, err := range users {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(user.Name)
+//	}
+func Paginate[T any](ctx context.Context, client *RestClient, rawURL string, opts PageOptions) iter.Seq2[T, error] {
+	if client == nil {
+		client = DefaultClient
+	}
+	pagination := opts.Pagination
+	if pagination == nil {
+		pagination = LinkPagination{}
+	}
+
+	return func(yield func(T, error) bool) {
+		var zero T
+		next, err := url.Parse(rawURL)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		if starter, ok := pagination.(pageStarter); ok {
+			next = starter.First(next)
+		}
+
+		fetched := make(map[string]bool)
+		for pages := 0; next != nil && (opts.MaxPages <= 0 || pages < opts.MaxPages); pages++ {
+			if fetched[next.String()] {
+				yield(zero, fmt.Errorf("%w: %s was already fetched", ErrPageLoop, next.Redacted()))
+				return
+			}
+			fetched[next.String()] = true
+			if err := waitLimiter(ctx, opts.Limiter); err != nil {
+				yield(zero, err)
+				return
+			}
+			page, items, err := fetchPage[T](ctx, client, next, opts)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			for _, item := range items {
+				if ctx.Err() != nil {
+					yield(zero, context.Cause(ctx))
+					return
+				}
+				if !yield(item, nil) {
+					return
+				}
+			}
+			if next, err = pagination.Next(page); err != nil {
+				yield(zero, err)
+				return
+			}
+		}
+	}
+}
+
+// fetchPage requests one page and decodes its items.
+func fetchPage[T any](ctx context.Context, client *RestClient, u *url.URL, opts PageOptions) (*Page, []T, error) {
+	if ctx.Err() != nil {
+		return nil, nil, context.Cause(ctx)
+	}
+	options := append(opts.RequestOptions[:len(opts.RequestOptions):len(opts.RequestOptions)],
+		WithHeader("Accept", ContentTypeApplicationJson), WithContext(ctx))
+	resp, err := client.send(http.MethodGet, u.String(), nil, options...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = resp.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !resp.IsSuccess() {
+		return nil, nil, fmt.Errorf("httpx: page request %s failed: %s", u.Redacted(), resp.Response.Status)
+	}
+
+	raw := json.RawMessage(body)
+	if opts.ItemsField != "" {
+		if raw, err = jsonPath(body, opts.ItemsField); err != nil {
+			return nil, nil, err
+		}
+	}
+	var items []T
+	if raw != nil {
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return nil, nil, fmt.Errorf("httpx: decoding page items: %w", err)
+		}
+	}
+	return &Page{URL: u, Header: resp.Header, Body: body, Items: len(items)}, items, nil
+}
+
+// waitLimiter takes a permit from l, giving up when ctx is done.
+func waitLimiter(ctx context.Context, l limiter.Limiter) error {
+	if l == nil {
+		return nil
+	}
+	for !l.TakeWithTimeout(50 * time.Millisecond) {
+		if ctx.Err() != nil {
+			return context.Cause(ctx)
+		}
+	}
+	return nil
+}
+
+// jsonPath returns the value at the dot-separated path in a JSON object, or nil
+// if a field along the path is missing or null.
+func jsonPath(body []byte, path string) (json.RawMessage, error) {
+	raw := json.RawMessage(body)
+	for _, key := range strings.Split(path, ".") {
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &obj); err != nil {
+			return nil, fmt.Errorf("httpx: reading %q from body: %w", path, err)
+		}
+		if obj == nil {
+			return nil, nil
+		}
+		var ok bool
+		if raw, ok = obj[key]; !ok {
+			return nil, nil
+		}
+	}
+	if string(raw) == "null" {
+		return nil, nil
+	}
+	return raw, nil
+}
+
+// withQuery returns a copy of u with the query parameter key set to value.
+func withQuery(u *url.URL, key, value string) *url.URL {
+	clone := *u
+	query := clone.Query()
+	query.Set(key, value)
+	clone.RawQuery = query.Encode()
+	return &clone
+}
+
+// parseLinkHeader maps the rel values of Link header values to their targets.
+func parseLinkHeader(values []string) map[string]string {
+	links := make(map[string]string)
+	for _, value := range values {
+		for _, link := range strings.Split(value, ",") {
+			parts := strings.Split(link, ";")
+			target := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			target = target[1 : len(target)-1]
+			for _, param := range parts[1:] {
+				name, val, ok := strings.Cut(strings.TrimSpace(param), "=")
+				if !ok || !strings.EqualFold(strings.TrimSpace(name), "rel") {
+					continue
+				}
+				for _, rel := range strings.Fields(strings.Trim(strings.TrimSpace(val), `"`)) {
+					links[strings.ToLower(rel)] = target
+				}
+			}
+		}
+	}
+	return links
+}
@@ -0,0 +1,249 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go4x/goal/limiter"
+)
+
+type pageItem struct {
+	ID int `json:"id"`
+}
+
+// collectIDs drains a pagination iterator, stopping at the first error.
+func collectIDs(seq func(func(pageItem, error) bool)) ([]int, error) {
+	var ids []int
+	for item, err := range seq {
+		if err != nil {
+			return ids, err
+		}
+		ids = append(ids, item.ID)
+	}
+	return ids, nil
+}
+
+// itemsJSON renders the ids from start to end (exclusive) as a JSON array.
+func itemsJSON(start, end int) string {
+	s := "["
+	for i := start; i < end; i++ {
+		if i > start {
+			s += ","
+		}
+		s += fmt.Sprintf(`{"id":%d}`, i)
+	}
+	return s + "]"
+}
+
+// nextPageLink returns a Link header to the page after the one requested by r,
+// for servers with endless pages.
+func nextPageLink(base string, r *http.Request) string {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	return fmt.Sprintf("<%s?page=%d>; rel=next", base, page+1)
+}
+
+func TestPaginate_Link(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page < 2 {
+			w.Header().Set("Link", fmt.Sprintf(`<%s/items?page=%d>; rel="next", <%s/items?page=2>; rel="last"`,
+				server.URL, page+1, server.URL))
+		}
+		_, _ = fmt.Fprint(w, itemsJSON(page*2, page*2+2))
+	}))
+	defer server.Close()
+
+	ids, err := collectIDs(Paginate[pageItem](context.Background(), nil, server.URL+"/items", PageOptions{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(ids) != "[0 1 2 3 4 5]" {
+		t.Errorf("Unexpected items %v", ids)
+	}
+}
+
+func TestPaginate_Cursor(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("cursor") {
+		case "":
+			_, _ = fmt.Fprintf(w, `{"data":%s,"meta":{"next_cursor":"abc"}}`, itemsJSON(0, 2))
+		case "abc":
+			_, _ = fmt.Fprintf(w, `{"data":%s,"meta":{"next_cursor":7}}`, itemsJSON(2, 4))
+		case "7":
+			_, _ = fmt.Fprintf(w, `{"data":%s,"meta":{"next_cursor":null}}`, itemsJSON(4, 5))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	ids, err := collectIDs(Paginate[pageItem](context.Background(), nil, server.URL+"?filter=x", PageOptions{
+		Pagination: CursorPagination{Field: "meta.next_cursor", Param: "cursor"},
+		ItemsField: "data",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(ids) != "[0 1 2 3 4]" {
+		t.Errorf("Unexpected items %v", ids)
+	}
+}
+
+func TestPaginate_RepeatedCursor(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		switch r.URL.Query().Get("cursor") {
+		case "":
+			_, _ = fmt.Fprintf(w, `{"data":%s,"next":"a"}`, itemsJSON(0, 1))
+		case "a":
+			_, _ = fmt.Fprintf(w, `{"data":%s,"next":"b"}`, itemsJSON(1, 2))
+		default:
+			_, _ = fmt.Fprintf(w, `{"data":%s,"next":"a"}`, itemsJSON(2, 3))
+		}
+	}))
+	defer server.Close()
+
+	ids, err := collectIDs(Paginate[pageItem](context.Background(), nil, server.URL, PageOptions{
+		Pagination: CursorPagination{Field: "next", Param: "cursor"},
+		ItemsField: "data",
+	}))
+	if !errors.Is(err, ErrPageLoop) {
+		t.Fatalf("Expected ErrPageLoop, got %v", err)
+	}
+	if fmt.Sprint(ids) != "[0 1 2]" || hits != 3 {
+		t.Errorf("Expected each page once before the loop, got %v in %d requests", ids, hits)
+	}
+}
+
+func TestPaginate_Offset(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		offset, _ := strconv.Atoi(r.URL.Query().Get("skip"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("take"))
+		_, _ = fmt.Fprint(w, itemsJSON(offset, min(offset+limit, 7)))
+	}))
+	defer server.Close()
+
+	ids, err := collectIDs(Paginate[pageItem](context.Background(), nil, server.URL, PageOptions{
+		Pagination: OffsetPagination{OffsetParam: "skip", LimitParam: "take", Limit: 3},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(ids) != "[0 1 2 3 4 5 6]" || requests != 3 {
+		t.Errorf("Unexpected items %v after %d requests", ids, requests)
+	}
+}
+
+func TestPaginate_StopsEarly(t *testing.T) {
+	var requests int32
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Link", nextPageLink(server.URL, r))
+		_, _ = fmt.Fprint(w, itemsJSON(0, 2))
+	}))
+	defer server.Close()
+
+	for _, err := range Paginate[pageItem](context.Background(), nil, server.URL, PageOptions{}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		break
+	}
+	if requests != 1 {
+		t.Errorf("Breaking out of the loop should stop fetching, got %d requests", requests)
+	}
+
+	ids, err := collectIDs(Paginate[pageItem](context.Background(), nil, server.URL, PageOptions{MaxPages: 2}))
+	if err != nil || len(ids) != 4 {
+		t.Errorf("Expected 4 items from 2 pages, got %v %v", ids, err)
+	}
+}
+
+func TestPaginate_ContextCancel(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Link", nextPageLink(server.URL, r))
+		_, _ = fmt.Fprint(w, itemsJSON(0, 2))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	n := 0
+	var lastErr error
+	for _, err := range Paginate[pageItem](ctx, nil, server.URL, PageOptions{}) {
+		if err != nil {
+			lastErr = err
+			break
+		}
+		if n++; n == 3 {
+			cancel()
+		}
+	}
+	if lastErr != context.Canceled || n != 3 {
+		t.Errorf("Expected context.Canceled after 3 items, got %v after %d", lastErr, n)
+	}
+}
+
+func TestPaginate_Errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/bad" {
+			_, _ = fmt.Fprint(w, `{"data":"oops"}`)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	if _, err := collectIDs(Paginate[pageItem](context.Background(), nil, server.URL, PageOptions{})); err == nil {
+		t.Error("Expected an error for a 500 page")
+	}
+	if _, err := collectIDs(Paginate[pageItem](context.Background(), nil, server.URL+"/bad", PageOptions{ItemsField: "data"})); err == nil {
+		t.Error("Expected a decoding error")
+	}
+}
+
+func TestPaginate_Limiter(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Link", nextPageLink(server.URL, r))
+		_, _ = fmt.Fprint(w, itemsJSON(0, 1))
+	}))
+	defer server.Close()
+
+	bucket := limiter.NewTokenBucket(1, 1, 100*time.Millisecond)
+	bucket.Start()
+	defer bucket.Stop()
+
+	start := time.Now()
+	ids, err := collectIDs(Paginate[pageItem](context.Background(), nil, server.URL, PageOptions{Limiter: bucket, MaxPages: 3}))
+	if err != nil || len(ids) != 3 {
+		t.Fatalf("Expected 3 items, got %v %v", ids, err)
+	}
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("Expected the limiter to space out pages, took %v", elapsed)
+	}
+}
+
+func TestParseLinkHeader(t *testing.T) {
+	links := parseLinkHeader([]string{
+		`<https://api.example.com/items?page=2>; rel="next", <https://api.example.com/items?page=9>; rel="last"`,
+		`<https://api.example.com/items?page=1>; title="x"; rel="first prev"`,
+	})
+	if links["next"] != "https://api.example.com/items?page=2" || links["last"] != "https://api.example.com/items?page=9" ||
+		links["prev"] != "https://api.example.com/items?page=1" {
+		t.Errorf("Unexpected links %v", links)
+	}
+}
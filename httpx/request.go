@@ -197,6 +197,18 @@ func WithAuthorization(authorization string) RequestOption {
 	}
 }
 
+// WithBasicAuth returns a RequestOption that sets HTTP Basic credentials.
+func WithBasicAuth(username, password string) RequestOption {
+	return func(req Request) {
+		req.GetRequest().SetBasicAuth(username, password)
+	}
+}
+
+// WithBearerToken returns a RequestOption that sets a bearer token.
+func WithBearerToken(token string) RequestOption {
+	return WithAuthorization("Bearer " + token)
+}
+
 // WithHeader returns a RequestOption that sets a header key-value pair.
 func WithHeader(key string, value string) RequestOption {
 	return func(req Request) {
@@ -0,0 +1,150 @@
+package httpx
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go4x/goal/errorx"
+)
+
+// Problem is an RFC 9457 problem details object, written by WriteProblem as
+// application/problem+json.
+type Problem struct {
+	// Type is a URI identifying the problem type, "about:blank" if empty.
+	Type string `json:"type,omitempty"`
+	// Title is a short summary of the problem type.
+	Title string `json:"title,omitempty"`
+	// Status is the HTTP status code.
+	Status int `json:"status,omitempty"`
+	// Detail explains this occurrence of the problem.
+	Detail string `json:"detail,omitempty"`
+	// Instance is a URI identifying this occurrence of the problem.
+	Instance string `json:"instance,omitempty"`
+	// Extensions are additional members of the problem object.
+	Extensions map[string]any `json:"-"`
+}
+
+// MarshalJSON encodes the problem with its extension members at the top level.
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	type problem Problem
+	data, err := json.Marshal((*problem)(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return data, err
+	}
+	members := make(map[string]any, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		members[k] = v
+	}
+	if err := json.Unmarshal(data, &members); err != nil {
+		return nil, err
+	}
+	return json.Marshal(members)
+}
+
+// WriteJSON writes v as a JSON response with the given status code.
+func WriteJSON(w http.ResponseWriter, status int, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", ContentTypeApplicationJson)
+	w.WriteHeader(status)
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// WriteProblem writes problem as an application/problem+json response. A zero status
+// is written as 500 and an empty title defaults to the status text.
+func WriteProblem(w http.ResponseWriter, problem *Problem) error {
+	p := *problem
+	if p.Status == 0 {
+		p.Status = http.StatusInternalServerError
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+	data, err := json.Marshal(&p)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", ContentTypeApplicationProblemJson)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// StatusOf returns the HTTP status code for err: the code of an errorx.PreferredError
+// in its chain, 400 if that error has no valid code, and 500 for any other error.
+func StatusOf(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+	var preferred *errorx.PreferredError
+	if !errors.As(err, &preferred) {
+		return http.StatusInternalServerError
+	}
+	if code := preferred.Code(); code >= 100 && code <= 599 {
+		return code
+	}
+	return http.StatusBadRequest
+}
+
+// WriteError writes err as a problem+json response with the status given by StatusOf.
+// The message of an errorx.PreferredError is meant for clients and is sent as the
+// detail; other errors may expose internals, so only the status text is sent.
+func WriteError(w http.ResponseWriter, r *http.Request, err error) error {
+	p := &Problem{Status: StatusOf(err)}
+	var preferred *errorx.PreferredError
+	if errors.As(err, &preferred) {
+		p.Detail = preferred.Error()
+	}
+	if r != nil {
+		p.Instance = r.URL.Path
+	}
+	return WriteProblem(w, p)
+}
+
+// HandlerFunc is an HTTP handler returning an error. Returned errors are written
+// with WriteError, so handlers can simply return errorx.Prefer400(...) and the like.
+//
+// Example:
+//
+//	mux.Handle("POST /users", httpx.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+//		var req CreateUser
+//		if err := httpx.Bind(r, &req); err != nil {
+//			return err // 400 or 415 problem+json
+//		}
+//		user, err := svc.Create(r.Context(), req)
+//		if err != nil {
+//			return err
+//		}
+//		return httpx.WriteJSON(w, http.StatusCreated, user)
+//	}))
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+// ServeHTTP implements http.Handler.
+func (f HandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := f(w, r); err != nil {
+		_ = WriteError(w, r, err)
+	}
+}
+
+// Recovery returns middleware that recovers from panics in next with errorx.Recover,
+// reports them to onPanic if not nil, and answers with a 500 problem+json response.
+// http.ErrAbortHandler is re-panicked so the server can abort the response.
+func Recovery(next http.Handler, onPanic func(r *http.Request, p any)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer errorx.Recover(func(p any) {
+			if p == http.ErrAbortHandler {
+				panic(p)
+			}
+			if onPanic != nil {
+				onPanic(r, p)
+			}
+			_ = WriteProblem(w, &Problem{Status: http.StatusInternalServerError, Instance: r.URL.Path})
+		})
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,126 @@
+package httpx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go4x/goal/errorx"
+)
+
+func TestStatusOf(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{nil, http.StatusOK},
+		{errors.New("boom"), http.StatusInternalServerError},
+		{errorx.Prefer429("slow down"), http.StatusTooManyRequests},
+		{fmt.Errorf("wrapped: %w", errorx.Prefer403("no")), http.StatusForbidden},
+		{errorx.NewPreferredCodeErrf(http.StatusNotFound, "missing"), http.StatusNotFound},
+		{errorx.NewPreferredErrCode(errors.New("bad"), 0), http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if got := StatusOf(tt.err); got != tt.want {
+			t.Errorf("StatusOf(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	rec := httptest.NewRecorder()
+	if err := WriteJSON(rec, http.StatusCreated, map[string]int{"id": 7}); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusCreated || rec.Header().Get("Content-Type") != ContentTypeApplicationJson ||
+		rec.Body.String() != "{\"id\":7}\n" {
+		t.Errorf("Unexpected response %d %v %q", rec.Code, rec.Header(), rec.Body.String())
+	}
+}
+
+func TestWriteProblem(t *testing.T) {
+	rec := httptest.NewRecorder()
+	problem := &Problem{
+		Type:       "https://example.com/probs/out-of-credit",
+		Status:     http.StatusForbidden,
+		Detail:     "Your balance is 30",
+		Extensions: map[string]any{"balance": 30, "status": "ignored"},
+	}
+	if err := WriteProblem(rec, problem); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusForbidden || rec.Header().Get("Content-Type") != ContentTypeApplicationProblemJson {
+		t.Errorf("Unexpected response %d %v", rec.Code, rec.Header())
+	}
+	var body map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body["title"] != "Forbidden" || body["status"] != float64(403) || body["balance"] != float64(30) {
+		t.Errorf("Unexpected problem %v", body)
+	}
+	if problem.Title != "" {
+		t.Error("WriteProblem should not modify the problem")
+	}
+}
+
+func TestHandlerFunc_WritesErrors(t *testing.T) {
+	handler := HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		switch r.URL.Path {
+		case "/preferred":
+			return fmt.Errorf("loading user: %w", errorx.Prefer401("token expired"))
+		case "/internal":
+			return errors.New("db password is hunter2")
+		}
+		return WriteJSON(w, http.StatusOK, "ok")
+	})
+
+	tests := []struct {
+		path, want string
+		status     int
+	}{
+		{"/preferred", `"detail":"token expired"`, http.StatusUnauthorized},
+		{"/internal", `"title":"Internal Server Error"`, http.StatusInternalServerError},
+		{"/", `"ok"`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.status || !strings.Contains(rec.Body.String(), tt.want) {
+			t.Errorf("%s: expected %d with %s, got %d %s", tt.path, tt.status, tt.want, rec.Code, rec.Body.String())
+		}
+		if strings.Contains(rec.Body.String(), "hunter2") {
+			t.Error("Internal errors should not be exposed")
+		}
+	}
+}
+
+func TestRecovery(t *testing.T) {
+	var recovered any
+	handler := Recovery(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}), func(r *http.Request, p any) { recovered = p })
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if rec.Code != http.StatusInternalServerError || !strings.Contains(rec.Body.String(), `"instance":"/panic"`) {
+		t.Errorf("Expected a 500 problem, got %d %s", rec.Code, rec.Body.String())
+	}
+	if recovered != "boom" {
+		t.Errorf("Expected onPanic to receive the panic value, got %v", recovered)
+	}
+
+	abort := Recovery(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}), nil)
+	defer func() {
+		if p := recover(); p != http.ErrAbortHandler {
+			t.Errorf("Expected http.ErrAbortHandler to be re-panicked, got %v", p)
+		}
+	}()
+	abort.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
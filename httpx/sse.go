@@ -0,0 +1,293 @@
+package httpx
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultSSERetry is the reconnection delay used by EventSource until the server
+// sends a retry field.
+const DefaultSSERetry = 3 * time.Second
+
+// Event is a single Server-Sent Event dispatched by an EventSource.
+type Event struct {
+	// ID is the value of the last id field seen by the stream, which is also sent
+	// back to the server in the Last-Event-ID header on reconnect.
+	ID string
+	// Type is the value of the event field, "message" if the server did not set one.
+	Type string
+	// Data is the event payload; multiple data lines are joined with "\n".
+	Data string
+	// Retry is the reconnection delay sent with this event, zero if none was sent.
+	Retry time.Duration
+}
+
+// EventSource is a Server-Sent Events (text/event-stream) client.
+//
+// It connects to URL with a GET request, parses the stream into Events and
+// reconnects automatically when the connection drops, sending the last seen
+// event id in the Last-Event-ID header and waiting for the retry delay
+// announced by the server.
+//
+// Example:
+//
+//	es := httpx.NewEventSource(nil, "https://api.example.com/events")
+//	for ev, err := range es.Events(ctx) {
+//		if err != nil {
+//			log.Printf("stream error, reconnecting: %v", err)
+//			continue
+//		}
+//		fmt.Println(ev.Type, ev.Data)
+//	}
+type EventSource struct {
+	// Client is the client used to connect, DefaultClient if nil.
+	// Its Timeout should be zero, otherwise the stream is cut after the timeout.
+	Client *RestClient
+	// URL is the address of the event stream.
+	URL string
+	// Options are applied to every connection request.
+	Options []RequestOption
+	// Retry is the current reconnection delay. It is updated by retry fields.
+	Retry time.Duration
+	// LastEventID is the id of the last event received; it is updated as events
+	// arrive and may be preset to resume a stream.
+	LastEventID string
+	// MaxRetries limits consecutive failed connection attempts, zero means unlimited.
+	MaxRetries int
+}
+
+// NewEventSource creates an EventSource for the given URL.
+// If client is nil, a client without timeout sharing DefaultClient's transport is used.
+func NewEventSource(client *RestClient, url string, options ...RequestOption) *EventSource {
+	if client == nil {
+		client = &RestClient{Client: &http.Client{Transport: DefaultClient.Transport}}
+	}
+	return &EventSource{Client: client, URL: url, Options: options, Retry: DefaultSSERetry}
+}
+
+// ErrSSEStop is yielded when the server tells the client to stop reconnecting
+// by answering with 204 No Content.
+var ErrSSEStop = errors.New("httpx: event stream closed by server")
+
+// Events connects to the stream and returns an iterator over received events.
+//
+// Connection and protocol errors are yielded with a nil event; if the caller keeps
+// iterating, the source waits for the retry delay and reconnects. Iteration ends
+// when the caller breaks, ctx is cancelled, the server answers 204 No Content,
+// or MaxRetries consecutive attempts have failed.
+func (es *EventSource) Events(ctx context.Context) iter.Seq2[*Event, error] {
+	return func(yield func(*Event, error) bool) {
+		failures := 0
+		for {
+			received, err := es.connect(ctx, yield)
+			if ctx.Err() != nil {
+				return
+			}
+			if err == errSSEBreak {
+				return
+			}
+			if err == ErrSSEStop {
+				yield(nil, err)
+				return
+			}
+			if received {
+				failures = 0
+			}
+			if err != nil {
+				failures++
+				if !yield(nil, err) {
+					return
+				}
+				if es.MaxRetries > 0 && failures >= es.MaxRetries {
+					return
+				}
+			}
+			if !sleepContext(ctx, es.retryDelay()) {
+				return
+			}
+		}
+	}
+}
+
+// errSSEBreak signals that the consumer stopped the iteration.
+var errSSEBreak = errors.New("httpx: event iteration stopped")
+
+// connect opens one connection and yields its events until the stream ends.
+// It reports whether at least one event was received.
+func (es *EventSource) connect(ctx context.Context, yield func(*Event, error) bool) (bool, error) {
+	client := es.Client
+	if client == nil {
+		client = DefaultClient
+	}
+	req, err := NewRequestWithContext(ctx, http.MethodGet, es.URL, nil, es.Options...)
+	if err != nil {
+		return false, err
+	}
+	req.WithHeader("Accept", ContentTypeTextEventStream)
+	req.WithHeader("Cache-Control", "no-cache")
+	if es.LastEventID != "" {
+		req.WithHeader("Last-Event-ID", es.LastEventID)
+	}
+
+	resp, err := client.Do(req.GetRequest())
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode == http.StatusNoContent {
+		return false, ErrSSEStop
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("httpx: unexpected event stream status: %s", resp.Status)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, ContentTypeTextEventStream) {
+		return false, fmt.Errorf("httpx: unexpected event stream content type: %q", ct)
+	}
+
+	received, stopped := false, false
+	err = parseEvents(resp.Body, func(ev *Event) bool {
+		received = true
+		es.LastEventID = ev.ID
+		stopped = !yield(ev, nil)
+		return !stopped
+	}, func(retry time.Duration) {
+		es.Retry = retry
+	})
+	if stopped {
+		return received, errSSEBreak
+	}
+	if err == nil {
+		err = io.ErrUnexpectedEOF
+	}
+	return received, err
+}
+
+// retryDelay returns the reconnection delay to wait before the next attempt.
+func (es *EventSource) retryDelay() time.Duration {
+	if es.Retry <= 0 {
+		return DefaultSSERetry
+	}
+	return es.Retry
+}
+
+// ParseEvents reads a text/event-stream from r and calls fn for every dispatched event.
+// Parsing follows the WHATWG Server-Sent Events rules: comment lines are ignored,
+// multiple data lines are joined with "\n", and events without data are not dispatched.
+// Parsing stops early when fn returns false. It returns nil at the end of the
+// stream or when stopped, otherwise the read error.
+func ParseEvents(r io.Reader, fn func(*Event) bool) error {
+	return parseEvents(r, fn, nil)
+}
+
+// parseEvents implements ParseEvents; onRetry, if not nil, is called as soon as a
+// valid retry field is read, even if no event is dispatched afterwards.
+func parseEvents(r io.Reader, fn func(*Event) bool, onRetry func(time.Duration)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), 1<<20)
+	scanner.Split(scanSSELines)
+
+	var (
+		lastID  string
+		evType  string
+		retry   time.Duration
+		data    strings.Builder
+		hasData bool
+		first   = true
+	)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if first {
+			line = strings.TrimPrefix(line, "\ufeff")
+			first = false
+		}
+		if line == "" {
+			if hasData {
+				ev := &Event{ID: lastID, Type: evType, Data: data.String(), Retry: retry}
+				if ev.Type == "" {
+					ev.Type = "message"
+				}
+				if !fn(ev) {
+					return nil
+				}
+			}
+			evType, retry, hasData = "", 0, false
+			data.Reset()
+			continue
+		}
+		if line[0] == ':' {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			evType = value
+		case "data":
+			if hasData {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+			hasData = true
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				lastID = value
+			}
+		case "retry":
+			if ms, err := strconv.ParseUint(value, 10, 63); err == nil {
+				retry = time.Duration(ms) * time.Millisecond
+				if onRetry != nil {
+					onRetry(retry)
+				}
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// scanSSELines is a bufio.SplitFunc accepting "\r\n", "\n" and "\r" line endings.
+func scanSSELines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\n' {
+			return i + 1, data[:i], nil
+		}
+		// A lone '\r' at the end of the buffer may be followed by '\n'.
+		if i+1 == len(data) && !atEOF {
+			return 0, nil, nil
+		}
+		if i+1 < len(data) && data[i+1] == '\n' {
+			return i + 2, data[:i], nil
+		}
+		return i + 1, data[:i], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// sleepContext waits for d or until ctx is done. It reports whether the full
+// duration elapsed.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,157 @@
+package httpx
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseEvents(t *testing.T) {
+	stream := "\ufeff: comment\n" +
+		"data: first\n\n" +
+		"event: update\r\nid: 7\r\ndata: line1\r\ndata:line2\r\n\r\n" +
+		"retry: 1500\rdata: third\r\r" +
+		"id: 8\n\n" +
+		"data\n\n"
+
+	var events []*Event
+	err := ParseEvents(strings.NewReader(stream), func(ev *Event) bool {
+		events = append(events, ev)
+		return true
+	})
+	if err != nil {
+		t.Fatalf("ParseEvents failed: %v", err)
+	}
+
+	expected := []Event{
+		{Type: "message", Data: "first"},
+		{ID: "7", Type: "update", Data: "line1\nline2"},
+		{ID: "7", Type: "message", Data: "third", Retry: 1500 * time.Millisecond},
+		{ID: "8", Type: "message", Data: ""},
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %d events, got %d: %+v", len(expected), len(events), events)
+	}
+	for i, ev := range events {
+		if *ev != expected[i] {
+			t.Errorf("Event %d: expected %+v, got %+v", i, expected[i], *ev)
+		}
+	}
+}
+
+func TestParseEvents_Stop(t *testing.T) {
+	stream := "data: a\n\ndata: b\n\ndata: c\n\n"
+	count := 0
+	err := ParseEvents(strings.NewReader(stream), func(ev *Event) bool {
+		count++
+		return count < 2
+	})
+	if err != nil {
+		t.Fatalf("ParseEvents failed: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected parsing to stop after 2 events, got %d", count)
+	}
+}
+
+func TestEventSource_Reconnect(t *testing.T) {
+	var connections int32
+	lastIDs := make(chan string, 3)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&connections, 1)
+		lastIDs <- r.Header.Get("Last-Event-ID")
+		w.Header().Set("Content-Type", ContentTypeTextEventStream)
+		switch n {
+		case 1:
+			fmt.Fprint(w, "retry: 10\nid: 1\ndata: one\n\nid: 2\ndata: two\n\n")
+		case 2:
+			fmt.Fprint(w, "id: 3\ndata: three\n\n")
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	es := NewEventSource(nil, server.URL)
+	var data []string
+	var errs []error
+	for ev, err := range es.Events(ctx) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		data = append(data, ev.Data)
+	}
+
+	if got := strings.Join(data, ","); got != "one,two,three" {
+		t.Errorf("Expected events one,two,three, got %s", got)
+	}
+	if len(errs) == 0 || errs[len(errs)-1] != ErrSSEStop {
+		t.Errorf("Expected iteration to end with ErrSSEStop, got %v", errs)
+	}
+	if es.Retry != 10*time.Millisecond {
+		t.Errorf("Expected retry to be updated to 10ms, got %v", es.Retry)
+	}
+	for _, want := range []string{"", "2", "3"} {
+		if got := <-lastIDs; got != want {
+			t.Errorf("Expected Last-Event-ID %q, got %q", want, got)
+		}
+	}
+}
+
+func TestEventSource_ContextCancel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ContentTypeTextEventStream)
+		fmt.Fprint(w, "data: hello\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for ev, err := range NewEventSource(nil, server.URL).Events(ctx) {
+			if err == nil && ev.Data == "hello" {
+				cancel()
+			}
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Iteration did not stop after context cancellation")
+	}
+}
+
+func TestEventSource_MaxRetries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	es := NewEventSource(nil, server.URL)
+	es.Retry = time.Millisecond
+	es.MaxRetries = 3
+
+	errs := 0
+	for _, err := range es.Events(context.Background()) {
+		if err == nil {
+			t.Fatal("Expected only errors")
+		}
+		errs++
+	}
+	if errs != 3 {
+		t.Errorf("Expected 3 errors before giving up, got %d", errs)
+	}
+}
@@ -0,0 +1,116 @@
+package httpx
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+)
+
+// DefaultMaxLineSize is the longest record Stream accepts, 1 MiB.
+const DefaultMaxLineSize = 1 << 20
+
+// ErrLineTooLong is the error of records longer than the maximum line size.
+var ErrLineTooLong = errors.New("httpx: line too long")
+
+// LineError reports a record of a line-delimited stream that could not be decoded.
+type LineError struct {
+	// Line is the 1-based line number of the record.
+	Line int
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("httpx: line %d: %v", e.Line, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// Stream returns an iterator decoding a newline-delimited JSON response body
+// (application/x-ndjson, application/jsonl, application/stream+json) one record at
+// a time, so that arbitrarily long streams are decoded in bounded memory. The body
+// is closed when the iteration ends.
+//
+// Records that cannot be decoded or are longer than DefaultMaxLineSize are yielded
+// as a *LineError and the iteration continues with the next line; the caller may
+// stop by breaking out of the loop. A read error is yielded once and ends the
+// iteration. Blank lines are skipped.
+//
+// Example:
+//
+//	resp, err := client.Get("https://api.example.com/events/export", nil)
+//	if err != nil {
+//		return err
+//	}
+//	for event, err := range httpx.Stream[Event](resp) {
+//		if err != nil {
+//			return err // a *httpx.LineError carries the line number
+//		}
+//		handle(event)
+//	}
+func Stream[T any](resp *Response) iter.Seq2[T, error] {
+	lines := DecodeLines[T](resp.Body, DefaultMaxLineSize)
+	return func(yield func(T, error) bool) {
+		defer func() { _ = resp.Close() }()
+		lines(yield)
+	}
+}
+
+// DecodeLines returns an iterator decoding each non-blank line of r as a JSON
+// value of type T. Lines may end with LF or CRLF and may not be longer than
+// maxLineSize bytes, DefaultMaxLineSize if zero or less. Errors are reported as
+// for Stream, but r is not closed.
+func DecodeLines[T any](r io.Reader, maxLineSize int) iter.Seq2[T, error] {
+	if maxLineSize <= 0 {
+		maxLineSize = DefaultMaxLineSize
+	}
+	return func(yield func(T, error) bool) {
+		var zero T
+		// The reader buffers one line plus its line terminator.
+		br := bufio.NewReaderSize(r, maxLineSize+2)
+		for n := 1; ; n++ {
+			line, err := br.ReadSlice('\n')
+			tooLong := errors.Is(err, bufio.ErrBufferFull) || len(bytes.TrimRight(line, "\r\n")) > maxLineSize
+			if errors.Is(err, bufio.ErrBufferFull) {
+				err = skipLine(br)
+			}
+			if err != nil && err != io.EOF {
+				yield(zero, err)
+				return
+			}
+
+			if tooLong {
+				if !yield(zero, &LineError{Line: n, Err: ErrLineTooLong}) {
+					return
+				}
+			} else if record := bytes.TrimSpace(line); len(record) > 0 {
+				var v T
+				if decodeErr := json.Unmarshal(record, &v); decodeErr != nil {
+					if !yield(zero, &LineError{Line: n, Err: decodeErr}) {
+						return
+					}
+				} else if !yield(v, nil) {
+					return
+				}
+			}
+			if err == io.EOF {
+				return
+			}
+		}
+	}
+}
+
+// skipLine discards the rest of the current line.
+func skipLine(br *bufio.Reader) error {
+	for {
+		_, err := br.ReadSlice('\n')
+		if !errors.Is(err, bufio.ErrBufferFull) {
+			return err
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package httpx
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+type streamRecord struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		for i := 1; i <= 3; i++ {
+			_, _ = fmt.Fprintf(w, "{\"id\":%d,\"name\":\"r%d\"}\n", i, i)
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer server.Close()
+
+	resp, err := NewRestClient(&http.Client{}).Get(server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for record, err := range Stream[streamRecord](resp) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, record.Name)
+	}
+	if strings.Join(names, ",") != "r1,r2,r3" {
+		t.Errorf("Unexpected records %v", names)
+	}
+	if _, err := resp.Body.Read(make([]byte, 1)); err == nil {
+		t.Error("Stream should close the body")
+	}
+}
+
+func TestDecodeLines_LineErrors(t *testing.T) {
+	input := "{\"id\":1}\r\n\n  \n{\"id\":\n{\"id\":" + strings.Repeat("9", 40) + "}\n{\"id\":5}"
+	var ids []int
+	var lineErrs []*LineError
+	for record, err := range DecodeLines[streamRecord](strings.NewReader(input), 32) {
+		var lineErr *LineError
+		if errors.As(err, &lineErr) {
+			lineErrs = append(lineErrs, lineErr)
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, record.ID)
+	}
+
+	if fmt.Sprint(ids) != "[1 5]" {
+		t.Errorf("Unexpected records %v", ids)
+	}
+	if len(lineErrs) != 2 || lineErrs[0].Line != 4 || lineErrs[1].Line != 5 {
+		t.Fatalf("Expected errors on lines 4 and 5, got %v", lineErrs)
+	}
+	if !errors.Is(lineErrs[1], ErrLineTooLong) {
+		t.Errorf("Expected ErrLineTooLong, got %v", lineErrs[1])
+	}
+	if !strings.HasPrefix(lineErrs[0].Error(), "httpx: line 4: ") {
+		t.Errorf("Unexpected message %q", lineErrs[0].Error())
+	}
+}
+
+func TestDecodeLines_BoundedMemory(t *testing.T) {
+	// A single endless line must be rejected without buffering it.
+	r := io.MultiReader(strings.NewReader(`{"name":"`), io.LimitReader(infiniteReader{}, 10<<20), strings.NewReader("\"}\n{\"id\":2}\n"))
+	var ids []int
+	var errs int
+	for record, err := range DecodeLines[streamRecord](r, 1024) {
+		if err != nil {
+			errs++
+			continue
+		}
+		ids = append(ids, record.ID)
+	}
+	if errs != 1 || fmt.Sprint(ids) != "[2]" {
+		t.Errorf("Expected one error and record 2, got %d errors and %v", errs, ids)
+	}
+}
+
+func TestDecodeLines_StopAndReadError(t *testing.T) {
+	n := 0
+	for range DecodeLines[streamRecord](strings.NewReader("{}\n{}\n{}\n"), 0) {
+		n++
+		break
+	}
+	if n != 1 {
+		t.Errorf("Expected to stop after one record, got %d", n)
+	}
+
+	boom := errors.New("boom")
+	var got []error
+	for _, err := range DecodeLines[streamRecord](io.MultiReader(strings.NewReader("{}\n"), iotest.ErrReader(boom)), 0) {
+		got = append(got, err)
+	}
+	if len(got) != 2 || got[0] != nil || !errors.Is(got[1], boom) {
+		t.Errorf("Expected a record then the read error, got %v", got)
+	}
+}
+
+// infiniteReader yields an endless stream of 'x'.
+type infiniteReader struct{}
+
+func (infiniteReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 'x'
+	}
+	return len(p), nil
+}
@@ -0,0 +1,221 @@
+package httpx
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Timings breaks down where the time of a request was spent. Phases that did not
+// happen, such as DNS and connect on a reused connection, are zero.
+type Timings struct {
+	// DNS is the duration of the host name lookup.
+	DNS time.Duration
+	// Connect is the duration of the TCP connection establishment.
+	Connect time.Duration
+	// TLS is the duration of the TLS handshake.
+	TLS time.Duration
+	// FirstByte is the time from the start of the request to the first response byte.
+	FirstByte time.Duration
+	// Total is the time from the start of the request until the response body was
+	// read to the end or closed, or until the response headers if it was not yet.
+	Total time.Duration
+	// Reused reports whether the request was sent on a pooled connection.
+	Reused bool
+}
+
+// TraceHook is notified around each request sent by a TraceTransport, e.g. to
+// start and end tracing spans.
+type TraceHook interface {
+	// StartRequest is called before req is sent. The returned context, derived
+	// from the request context, is used to send the request, so hooks can carry
+	// span state to EndRequest or inject it into the request headers.
+	StartRequest(req *http.Request) context.Context
+	// EndRequest is called once the response body has been read to the end or
+	// closed, or when the request failed. req carries the context returned by
+	// StartRequest; resp is nil if err is not.
+	EndRequest(req *http.Request, resp *http.Response, err error, timings Timings)
+}
+
+// TraceTransport is an http.RoundTripper that measures each request with
+// net/http/httptrace and reports it to its hooks. The timings of a response are
+// available through Response.Timings.
+type TraceTransport struct {
+	// Hooks are notified in order when requests start and in reverse order when they end.
+	Hooks []TraceHook
+	// Transport sends the requests, http.DefaultTransport if nil.
+	Transport http.RoundTripper
+}
+
+// NewTraceTransport creates a TraceTransport sending requests with next,
+// http.DefaultTransport if nil.
+func NewTraceTransport(next http.RoundTripper, hooks ...TraceHook) *TraceTransport {
+	return &TraceTransport{Hooks: hooks, Transport: next}
+}
+
+// timingsKey is the context key of the recorder of a traced request.
+type timingsKey struct{}
+
+// RoundTrip implements http.RoundTripper.
+func (t *TraceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport := t.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	ctx := req.Context()
+	if len(t.Hooks) > 0 {
+		// Hooks may add headers, which must not leak into the caller's request.
+		req = req.Clone(ctx)
+	}
+	for _, hook := range t.Hooks {
+		ctx = hook.StartRequest(req.WithContext(ctx))
+	}
+	rec := &timingRecorder{start: time.Now()}
+	ctx = context.WithValue(httptrace.WithClientTrace(ctx, rec.clientTrace()), timingsKey{}, rec)
+	traced := req.WithContext(ctx)
+
+	resp, err := transport.RoundTrip(traced)
+	rec.finish()
+	if err != nil {
+		t.end(traced, nil, err, rec)
+		return nil, err
+	}
+	resp.Body = &tracedBody{ReadCloser: resp.Body, end: sync.OnceFunc(func() {
+		rec.finish()
+		t.end(traced, resp, nil, rec)
+	})}
+	return resp, nil
+}
+
+// end notifies the hooks that the request is complete.
+func (t *TraceTransport) end(req *http.Request, resp *http.Response, err error, rec *timingRecorder) {
+	timings := rec.timings()
+	for i := len(t.Hooks) - 1; i >= 0; i-- {
+		t.Hooks[i].EndRequest(req, resp, err, timings)
+	}
+}
+
+// WithTrace returns a copy of the client that records request timings, exposed
+// by Response.Timings, and notifies hooks around each request. The original
+// client is not modified.
+//
+// Example:
+//
+//	client := httpx.NewRestClient(nil).WithTrace()
+//	resp, err := client.Get("https://api.example.com/users", nil)
+//	if err == nil {
+//		t, _ := resp.Timings()
+//		log.Printf("dns=%v connect=%v tls=%v ttfb=%v", t.DNS, t.Connect, t.TLS, t.FirstByte)
+//	}
+func (c *RestClient) WithTrace(hooks ...TraceHook) *RestClient {
+	return c.withTransport(func(next http.RoundTripper) http.RoundTripper {
+		return NewTraceTransport(next, hooks...)
+	})
+}
+
+// Timings returns the timings of the request, and false if the request was not
+// sent through a TraceTransport. Until the body is read to the end or closed,
+// Total stops at the arrival of the response headers; it then covers the body.
+func (r *Response) Timings() (Timings, bool) {
+	if r.Request == nil {
+		return Timings{}, false
+	}
+	rec, ok := r.Request.Context().Value(timingsKey{}).(*timingRecorder)
+	if !ok {
+		return Timings{}, false
+	}
+	return rec.timings(), true
+}
+
+// timingRecorder collects httptrace events. Its callbacks may run on the
+// transport's dialing goroutines, hence the mutex.
+type timingRecorder struct {
+	mu           sync.Mutex
+	start        time.Time
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	firstByte    time.Time
+	done         time.Time
+	reused       bool
+}
+
+func (r *timingRecorder) clientTrace() *httptrace.ClientTrace {
+	now := func(t *time.Time, overwrite bool) {
+		r.mu.Lock()
+		if overwrite || t.IsZero() {
+			*t = time.Now()
+		}
+		r.mu.Unlock()
+	}
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { now(&r.dnsStart, false) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { now(&r.dnsDone, true) },
+		// Several addresses may be dialled in parallel; measure from the first
+		// attempt to the last completion.
+		ConnectStart:         func(string, string) { now(&r.connectStart, false) },
+		ConnectDone:          func(string, string, error) { now(&r.connectDone, true) },
+		TLSHandshakeStart:    func() { now(&r.tlsStart, false) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { now(&r.tlsDone, true) },
+		GotFirstResponseByte: func() { now(&r.firstByte, false) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			r.mu.Lock()
+			r.reused = info.Reused
+			r.mu.Unlock()
+		},
+	}
+}
+
+// finish records the current time as the end of the request.
+func (r *timingRecorder) finish() {
+	r.mu.Lock()
+	r.done = time.Now()
+	r.mu.Unlock()
+}
+
+func (r *timingRecorder) timings() Timings {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	since := func(from, to time.Time) time.Duration {
+		if from.IsZero() || to.IsZero() {
+			return 0
+		}
+		return to.Sub(from)
+	}
+	return Timings{
+		DNS:       since(r.dnsStart, r.dnsDone),
+		Connect:   since(r.connectStart, r.connectDone),
+		TLS:       since(r.tlsStart, r.tlsDone),
+		FirstByte: since(r.start, r.firstByte),
+		Total:     since(r.start, r.done),
+		Reused:    r.reused,
+	}
+}
+
+// tracedBody ends the trace when the body is read to the end or closed.
+type tracedBody struct {
+	io.ReadCloser
+	end func()
+}
+
+func (b *tracedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.end()
+	}
+	return n, err
+}
+
+func (b *tracedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.end()
+	return err
+}
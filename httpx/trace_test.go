@@ -0,0 +1,190 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRestClient_WithTrace_Timings(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer server.Close()
+
+	client := NewRestClient(server.Client()).WithTrace()
+	resp, err := client.Get(server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := resp.Bytes(); err != nil {
+		t.Fatal(err)
+	}
+
+	timings, ok := resp.Timings()
+	if !ok {
+		t.Fatal("Expected timings on a traced response")
+	}
+	if timings.Connect <= 0 || timings.TLS <= 0 {
+		t.Errorf("Expected connect and TLS timings, got %+v", timings)
+	}
+	if timings.FirstByte < 20*time.Millisecond || timings.Total < timings.FirstByte || timings.Reused {
+		t.Errorf("Unexpected timings %+v", timings)
+	}
+
+	resp, err = client.Get(server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Close()
+	if timings, _ = resp.Timings(); !timings.Reused || timings.Connect != 0 || timings.TLS != 0 {
+		t.Errorf("Expected a reused connection without handshakes, got %+v", timings)
+	}
+}
+
+func TestRestClient_WithTrace_TotalCoversBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		time.Sleep(30 * time.Millisecond)
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer server.Close()
+
+	resp, err := NewRestClient(&http.Client{}).WithTrace().Get(server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	headers, _ := resp.Timings()
+	if headers.Total >= 30*time.Millisecond {
+		t.Errorf("Expected the total to stop at the headers, got %+v", headers)
+	}
+	if _, err := resp.Bytes(); err != nil {
+		t.Fatal(err)
+	}
+	if body, _ := resp.Timings(); body.Total < 30*time.Millisecond {
+		t.Errorf("Expected the total to cover the body, got %+v", body)
+	}
+}
+
+func TestRestClient_WithTrace_DNS(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	url := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+	resp, err := NewRestClient(&http.Client{}).WithTrace().Get(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Close()
+	if timings, _ := resp.Timings(); timings.DNS <= 0 || timings.TLS != 0 {
+		t.Errorf("Expected a DNS lookup without TLS, got %+v", timings)
+	}
+}
+
+func TestResponse_Timings_Untraced(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	resp, err := NewRestClient(&http.Client{}).Get(server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Close()
+	if _, ok := resp.Timings(); ok {
+		t.Error("Untraced responses should have no timings")
+	}
+}
+
+type spanKey struct{}
+
+// recordingHook records the spans it starts and ends.
+type recordingHook struct {
+	name   string
+	events *[]string
+	ended  chan Timings
+}
+
+func (h *recordingHook) StartRequest(req *http.Request) context.Context {
+	*h.events = append(*h.events, "start "+h.name)
+	req.Header.Set("X-Span-"+h.name, "1")
+	return context.WithValue(req.Context(), spanKey{}, h.name)
+}
+
+func (h *recordingHook) EndRequest(req *http.Request, resp *http.Response, err error, timings Timings) {
+	status := "error"
+	if err == nil {
+		status = resp.Status
+	}
+	*h.events = append(*h.events, "end "+h.name+" "+req.Context().Value(spanKey{}).(string)+" "+status)
+	if h.ended != nil {
+		h.ended <- timings
+	}
+}
+
+func TestTraceTransport_Hooks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Header.Get("X-Span-a")+r.Header.Get("X-Span-b"))
+	}))
+	defer server.Close()
+
+	var events []string
+	ended := make(chan Timings, 1)
+	client := NewRestClient(&http.Client{}).WithTrace(
+		&recordingHook{name: "a", events: &events},
+		&recordingHook{name: "b", events: &events, ended: ended},
+	)
+	resp, err := client.Get(server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Errorf("Spans should stay open until the body is consumed, got %v", events)
+	}
+	if body, _ := resp.String(); body != "11" {
+		t.Errorf("Hooks should be able to inject headers, got %q", body)
+	}
+	if timings := <-ended; timings.Total <= 0 {
+		t.Errorf("Expected a total duration, got %+v", timings)
+	}
+	_ = resp.Close()
+
+	want := "start a,start b,end b b 200 OK,end a b 200 OK"
+	if got := strings.Join(events, ","); got != want {
+		t.Errorf("Expected events %q, got %q", want, got)
+	}
+
+	events = nil
+	if _, err := client.Get("http://127.0.0.1:1", nil); err == nil {
+		t.Fatal("Expected a connection error")
+	}
+	<-ended
+	if len(events) != 4 || !strings.HasSuffix(events[3], "error") {
+		t.Errorf("Failed requests should end their spans, got %v", events)
+	}
+}
+
+func TestTraceTransport_ContextCancel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	var events []string
+	ended := make(chan Timings, 1)
+	client := NewRestClient(&http.Client{}).WithTrace(&recordingHook{name: "a", events: &events, ended: ended})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := client.send(http.MethodGet, server.URL, nil, WithContext(ctx)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got %v", err)
+	}
+	if timings := <-ended; timings.Total < 20*time.Millisecond {
+		t.Errorf("Expected the total to cover the wait, got %+v", timings)
+	}
+}
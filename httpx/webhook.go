@@ -0,0 +1,211 @@
+package httpx
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go4x/goal/errorx"
+)
+
+const (
+	// WebhookTimestampHeader carries the Unix time at which a webhook was signed.
+	WebhookTimestampHeader = "Webhook-Timestamp"
+	// WebhookSignatureHeader carries the webhook signatures, comma separated
+	// "v1=<hex HMAC-SHA256>" entries, one per signing secret.
+	WebhookSignatureHeader = "Webhook-Signature"
+	// DefaultWebhookTolerance is how far a webhook timestamp may be from the
+	// current time before the webhook is rejected as a replay.
+	DefaultWebhookTolerance = 5 * time.Minute
+	// MaxWebhookBodySize is the largest webhook body Webhook.Verify reads, 1 MiB.
+	MaxWebhookBodySize = 1 << 20
+
+	webhookScheme = "v1"
+)
+
+var (
+	// ErrWebhookNoSignature is returned when a webhook lacks its timestamp or signature.
+	ErrWebhookNoSignature = errors.New("httpx: webhook signature missing")
+	// ErrWebhookSignature is returned when no signature matches any secret.
+	ErrWebhookSignature = errors.New("httpx: webhook signature mismatch")
+	// ErrWebhookTimestamp is returned when the webhook timestamp is invalid or
+	// outside the replay window.
+	ErrWebhookTimestamp = errors.New("httpx: webhook timestamp outside tolerance")
+)
+
+// Webhook signs and verifies webhooks with HMAC-SHA256, in the style of Stripe and
+// GitHub. The signed payload is the timestamp, a dot and the raw body, and the
+// signature is sent hex encoded in WebhookSignatureHeader next to the timestamp
+// in WebhookTimestampHeader.
+//
+// Secrets lists the active secrets to support rotation: senders sign with every
+// secret and receivers accept a signature made with any of them. To rotate, add
+// the new secret on both sides, then remove the old one.
+//
+// Example:
+//
+//	hook := &httpx.Webhook{Secrets: [][]byte{newSecret, oldSecret}}
+//
+//	// Sender
+//	resp, err := client.PostJson(url, body, hook.Sign())
+//
+//	// Receiver
+//	mux.Handle("POST /webhooks", hook.Middleware(handler))
+type Webhook struct {
+	// Secrets are the active signing secrets.
+	Secrets [][]byte
+	// Tolerance is the replay window, DefaultWebhookTolerance if zero.
+	// Negative values disable the timestamp check.
+	Tolerance time.Duration
+
+	// now returns the current time; it is replaced in tests.
+	now func() time.Time
+}
+
+// Sign returns a RequestOption that signs the request body with every secret and
+// sets the timestamp and signature headers. The body is read into memory.
+func (h *Webhook) Sign() RequestOption {
+	return func(req Request) {
+		hreq := req.GetRequest()
+		if err := h.SignRequest(hreq); err != nil {
+			hreq.Body = io.NopCloser(errReader{err})
+			hreq.GetBody = nil
+			hreq.ContentLength = -1
+		}
+	}
+}
+
+// SignRequest signs the body of req with every secret and sets the timestamp and
+// signature headers.
+func (h *Webhook) SignRequest(req *http.Request) error {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+		req.ContentLength = int64(len(body))
+	}
+	timestamp := h.currentTime().Unix()
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookSignatureHeader, h.Signature(timestamp, body))
+	return nil
+}
+
+// Signature returns the WebhookSignatureHeader value for body signed at timestamp.
+func (h *Webhook) Signature(timestamp int64, body []byte) string {
+	signatures := make([]string, len(h.Secrets))
+	for i, secret := range h.Secrets {
+		signatures[i] = webhookScheme + "=" + hex.EncodeToString(webhookMAC(secret, timestamp, body))
+	}
+	return strings.Join(signatures, ",")
+}
+
+// Verify checks the signature and timestamp of r and returns its body, which is
+// also restored on r so it can be read again. Bodies larger than
+// MaxWebhookBodySize are rejected.
+func (h *Webhook) Verify(r *http.Request) ([]byte, error) {
+	var body []byte
+	if r.Body != nil && r.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(http.MaxBytesReader(nil, r.Body, MaxWebhookBodySize))
+		if err != nil {
+			return nil, err
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	if err := h.VerifyPayload(r.Header.Get(WebhookTimestampHeader), r.Header.Get(WebhookSignatureHeader), body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// VerifyPayload checks that signature, a WebhookSignatureHeader value, holds a
+// valid signature of body made at timestamp with any of the secrets, and that
+// timestamp is within the replay window. Signatures are compared in constant time.
+func (h *Webhook) VerifyPayload(timestamp, signature string, body []byte) error {
+	if timestamp == "" || signature == "" {
+		return ErrWebhookNoSignature
+	}
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrWebhookTimestamp
+	}
+	tolerance := h.Tolerance
+	if tolerance == 0 {
+		tolerance = DefaultWebhookTolerance
+	}
+	if tolerance > 0 {
+		if age := h.currentTime().Sub(time.Unix(ts, 0)); age > tolerance || age < -tolerance {
+			return ErrWebhookTimestamp
+		}
+	}
+
+	expected := make([][]byte, len(h.Secrets))
+	for i, secret := range h.Secrets {
+		expected[i] = webhookMAC(secret, ts, body)
+	}
+	for _, entry := range strings.Split(signature, ",") {
+		scheme, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || scheme != webhookScheme {
+			continue
+		}
+		mac, err := hex.DecodeString(value)
+		if err != nil {
+			continue
+		}
+		for _, want := range expected {
+			if hmac.Equal(mac, want) {
+				return nil
+			}
+		}
+	}
+	return ErrWebhookSignature
+}
+
+// Middleware returns a handler that verifies webhooks before passing them to next.
+// Requests failing verification are answered with a 401 problem+json response,
+// and bodies that are too large with 413.
+func (h *Webhook) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := h.Verify(r); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				err = errorx.NewPreferredCodeErrf(http.StatusRequestEntityTooLarge, "webhook body exceeds %d bytes", tooLarge.Limit)
+			} else {
+				err = errorx.NewPreferredErrCode(err, http.StatusUnauthorized)
+			}
+			_ = WriteError(w, r, err)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (h *Webhook) currentTime() time.Time {
+	if h.now != nil {
+		return h.now()
+	}
+	return time.Now()
+}
+
+func webhookMAC(secret []byte, timestamp int64, body []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return mac.Sum(nil)
+}
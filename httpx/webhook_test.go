@@ -0,0 +1,131 @@
+package httpx
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWebhook_SignAndVerify(t *testing.T) {
+	sender := &Webhook{Secrets: [][]byte{[]byte("whsec_new")}}
+	receiver := &Webhook{Secrets: [][]byte{[]byte("whsec_new"), []byte("whsec_old")}}
+
+	var received string
+	server := httptest.NewServer(receiver.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = string(body)
+	})))
+	defer server.Close()
+
+	client := NewRestClient(&http.Client{})
+	resp, err := client.PostJson(server.URL, strings.NewReader(`{"event":"paid"}`), sender.Sign())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || received != `{"event":"paid"}` {
+		t.Errorf("Expected the webhook to be accepted, got %d %q", resp.StatusCode, received)
+	}
+
+	forged := &Webhook{Secrets: [][]byte{[]byte("guess")}}
+	resp, err = client.PostJson(server.URL, strings.NewReader(`{"event":"paid"}`), forged.Sign())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusUnauthorized || resp.HeaderValue("Content-Type") != ContentTypeApplicationProblemJson {
+		t.Errorf("Expected a 401 problem for a forged webhook, got %d", resp.StatusCode)
+	}
+}
+
+func TestWebhook_Rotation(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	body := []byte("payload")
+	old := &Webhook{Secrets: [][]byte{[]byte("old")}, now: func() time.Time { return now }}
+	both := &Webhook{Secrets: [][]byte{[]byte("new"), []byte("old")}, now: func() time.Time { return now }}
+	current := &Webhook{Secrets: [][]byte{[]byte("new")}, now: func() time.Time { return now }}
+	ts := strconv.FormatInt(now.Unix(), 10)
+
+	signature := both.Signature(now.Unix(), body)
+	if strings.Count(signature, "v1=") != 2 {
+		t.Fatalf("Expected one signature per secret, got %q", signature)
+	}
+	for _, receiver := range []*Webhook{old, both, current} {
+		if err := receiver.VerifyPayload(ts, signature, body); err != nil {
+			t.Errorf("Signature made with both secrets should verify with %d secrets: %v", len(receiver.Secrets), err)
+		}
+	}
+	if err := both.VerifyPayload(ts, old.Signature(now.Unix(), body), body); err != nil {
+		t.Errorf("Old signatures should verify during rotation: %v", err)
+	}
+	if err := current.VerifyPayload(ts, old.Signature(now.Unix(), body), body); !errors.Is(err, ErrWebhookSignature) {
+		t.Errorf("Old signatures should be rejected after rotation, got %v", err)
+	}
+}
+
+func TestWebhook_VerifyPayloadErrors(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	hook := &Webhook{Secrets: [][]byte{[]byte("secret")}, now: func() time.Time { return now }}
+	body := []byte("payload")
+	sign := func(ts time.Time) (string, string) {
+		return strconv.FormatInt(ts.Unix(), 10), hook.Signature(ts.Unix(), body)
+	}
+
+	ts, sig := sign(now)
+	staleTs, staleSig := sign(now.Add(-10 * time.Minute))
+	futureTs, futureSig := sign(now.Add(10 * time.Minute))
+	tests := []struct {
+		name           string
+		timestamp, sig string
+		body           []byte
+		want           error
+	}{
+		{"missing signature", ts, "", body, ErrWebhookNoSignature},
+		{"missing timestamp", "", sig, body, ErrWebhookNoSignature},
+		{"bad timestamp", "yesterday", sig, body, ErrWebhookTimestamp},
+		{"replayed", staleTs, staleSig, body, ErrWebhookTimestamp},
+		{"future", futureTs, futureSig, body, ErrWebhookTimestamp},
+		{"tampered body", ts, sig, []byte("payload!"), ErrWebhookSignature},
+		{"other timestamp", strconv.FormatInt(now.Unix()-1, 10), sig, body, ErrWebhookSignature},
+		{"unknown scheme", ts, strings.Replace(sig, "v1=", "v0=", 1), body, ErrWebhookSignature},
+		{"malformed", ts, "v1=zz", body, ErrWebhookSignature},
+		{"valid", ts, "v0=ignored, " + sig, body, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := hook.VerifyPayload(tt.timestamp, tt.sig, tt.body); !errors.Is(err, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+
+	hook.Tolerance = -1
+	if err := hook.VerifyPayload(staleTs, staleSig, body); err != nil {
+		t.Errorf("A negative tolerance should disable the replay check: %v", err)
+	}
+}
+
+func TestWebhook_VerifyRestoresBody(t *testing.T) {
+	hook := &Webhook{Secrets: [][]byte{[]byte("secret")}}
+	req := MustNewRequest(http.MethodPost, "http://example.com/hook", strings.NewReader("data"), hook.Sign()).GetRequest()
+
+	body, err := hook.Verify(req)
+	if err != nil || string(body) != "data" {
+		t.Fatalf("Verify() = %q, %v", body, err)
+	}
+	again, _ := io.ReadAll(req.Body)
+	if string(again) != "data" {
+		t.Errorf("Expected the body to be readable after Verify, got %q", again)
+	}
+
+	large := MustNewRequest(http.MethodPost, "http://example.com/hook",
+		strings.NewReader(strings.Repeat("a", MaxWebhookBodySize+1)), hook.Sign()).GetRequest()
+	rec := httptest.NewRecorder()
+	hook.Middleware(http.NotFoundHandler()).ServeHTTP(rec, large)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413 for an oversized webhook, got %d", rec.Code)
+	}
+}
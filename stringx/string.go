@@ -556,3 +556,17 @@ func ContainsAll(s string, substrings ...string) bool {
 	}
 	return true
 }
+
+// ShellQuote returns s quoted for a POSIX shell, so that it is read back as a
+// single word. Words made of safe characters only are returned unchanged.
+func ShellQuote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	if strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:=@%+,", r))
+	}) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -619,3 +619,20 @@ func TestContainsAll(t *testing.T) {
 		}
 	}
 }
+
+func TestShellQuote(t *testing.T) {
+	cases := map[string]string{
+		"":            "''",
+		"-H":          "-H",
+		"a=b,c:d@e/f": "a=b,c:d@e/f",
+		"a b":         "'a b'",
+		"$HOME":       "'$HOME'",
+		"O'Brien":     `'O'\''Brien'`,
+		"line\nbreak": "'line\nbreak'",
+	}
+	for in, expect := range cases {
+		if got := stringx.ShellQuote(in); got != expect {
+			t.Errorf("ShellQuote(%q) = %q, expect %q", in, got, expect)
+		}
+	}
+}